@@ -0,0 +1,546 @@
+// SPDX-FileCopyrightText: 2021 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+package git
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-git/go-billy/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+	"github.com/go-git/go-git/v5/plumbing/format/gitattributes"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/storage"
+)
+
+var (
+	ErrSubmoduleMissing = errors.New("the submodule commit is not available locally")
+	ErrArchiveFileName  = errors.New("the added file name must be a relative path inside the archive")
+)
+
+// ArchiveFile is a file that isn't in the repo added to an archive.
+type ArchiveFile struct {
+	// Name is the slash separated path of the file below the archive
+	// prefix.
+	Name string
+
+	Data       []byte
+	Executable bool
+}
+
+// Archive is an archive created from the repo.
+type Archive struct {
+	// File is the path of the archive.
+	File string
+
+	// Excluded are the paths left out by the export-ignore attribute.
+	Excluded []string
+
+	// Substituted are the files with placeholders expanded by the
+	// export-subst attribute.
+	Substituted []string
+
+	// Added are the names of the files that aren't in the repo.
+	Added []string
+
+	// LFS are the files whose Git LFS pointer was replaced by the object.
+	LFS []string
+}
+
+// archiveEntry is a file, directory or symlink placed in an archive.
+type archiveEntry struct {
+	name string
+	mode filemode.FileMode
+	data []byte
+}
+
+// isDir returns true for directories, which includes submodules since only
+// the empty directory is archived for them.
+func (e *archiveEntry) isDir() bool {
+	return e.mode == filemode.Dir || e.mode == filemode.Submodule
+}
+
+// CreateArchive creates the archive of the tree of the version based on the
+// naming conventions.  If subdir is specified only that directory of the
+// repo is archived.  The archive is built from the repo objects, so neither
+// the working tree nor a git binary is used, and the same version always
+// produces the same bytes.  If the Submodules option is set the trees of the
+// submodules at their recorded commits are included as well, and if the LFS
+// option is set the files with the filter=lfs attribute hold the Git LFS
+// objects instead of the pointers.
+//
+// Like git archive, the .gitattributes files of the version are read: paths
+// with the export-ignore attribute are left out and files with the
+// export-subst attribute have their $Format:...$ placeholders expanded.
+//
+// The extra files are added to the archive like the files of the repo,
+// replacing any with the same name.
+func (g *Git) CreateArchive(slug, version, subdir string, format Format, path string, extra []ArchiveFile) (Archive, error) {
+	var a Archive
+	if err := format.validate(); err != nil {
+		return a, err
+	}
+
+	commit, entries, err := g.archiveEntries(version, subdir, slug+"/", &a)
+	if err != nil {
+		return a, err
+	}
+	if entries, err = addFiles(entries, slug+"/", extra, &a); err != nil {
+		return a, err
+	}
+
+	file := path + "/" + slug + "." + format.Name
+	f, err := os.Create(file)
+	if err != nil {
+		return a, fmt.Errorf("%w: unable to create file '%s'", err, file)
+	}
+
+	err = writeArchive(f, format, commit, entries)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		_ = os.Remove(file)
+		return a, fmt.Errorf("%w: unable to generate the %s archive", err, format.Name)
+	}
+
+	a.File = file
+	return a, nil
+}
+
+// archiveEntries returns the commit the revision refers to along with every
+// entry of the tree, or of the subdir of the tree, sorted by name.  Each name
+// starts with the prefix.  The paths left out or substituted are added to
+// the archive.
+func (g *Git) archiveEntries(rev, subdir, prefix string, a *Archive) (*object.Commit, []archiveEntry, error) {
+	hash, err := g.repo.ResolveRevision(plumbing.Revision(rev))
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: repo.ResolveRevision() error for '%s'", err, rev)
+	}
+
+	commit, err := g.repo.CommitObject(*hash)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: '%s' is not a commit", err, rev)
+	}
+
+	tree, err := commit.Tree()
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: commit.Tree() error for '%s'", err, rev)
+	}
+
+	var attrs []gitattributes.MatchAttribute
+	if subdir != "" {
+		if attrs, err = parentAttributes(tree, subdir); err != nil {
+			return nil, nil, err
+		}
+		if tree, err = tree.Tree(subdir); err != nil {
+			return nil, nil, fmt.Errorf("%w: unable to find the directory '%s' in '%s'", err, subdir, rev)
+		}
+	}
+
+	w := &treeWalker{
+		storer:     g.repo.Storer,
+		commit:     commit,
+		submodules: g.opts.Submodules,
+		archive:    a,
+		prefix:     prefix,
+	}
+	if w.submodules {
+		if w.modules, err = readModules(commit); err != nil {
+			return nil, nil, err
+		}
+	}
+	if g.opts.LFS {
+		if w.lfs, err = lfsObjects(g.repo.Storer); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	dir := ""
+	if subdir != "" {
+		dir = subdir + "/"
+	}
+
+	entries := []archiveEntry{{name: prefix, mode: filemode.Dir}}
+	entries, err = w.appendEntries(entries, tree, dir, prefix, attrs)
+	if err != nil {
+		return nil, nil, err
+	}
+	sortEntries(entries)
+
+	return commit, entries, nil
+}
+
+// treeWalker collects the entries of the trees of a repo.
+type treeWalker struct {
+	storer     storage.Storer
+	commit     *object.Commit
+	submodules bool
+
+	// modules are the submodule names keyed by path.
+	modules map[string]string
+
+	// lfs is the directory of the Git LFS objects, or nil if the pointer
+	// files are archived as is.
+	lfs billy.Filesystem
+
+	// archive collects the paths, without the prefix, that are left out
+	// or substituted.
+	archive *Archive
+	prefix  string
+}
+
+// readModules returns the names of the submodules listed in the .gitmodules
+// file of the commit keyed by path.
+func readModules(commit *object.Commit) (map[string]string, error) {
+	modules := map[string]string{}
+
+	f, err := commit.File(".gitmodules")
+	if err != nil {
+		if errors.Is(err, object.ErrFileNotFound) {
+			return modules, nil
+		}
+		return nil, fmt.Errorf("%w: unable to read the .gitmodules file", err)
+	}
+
+	contents, err := f.Contents()
+	if err != nil {
+		return nil, fmt.Errorf("%w: unable to read the .gitmodules file", err)
+	}
+
+	m := config.NewModules()
+	if err = m.Unmarshal([]byte(contents)); err != nil {
+		return nil, fmt.Errorf("%w: unable to parse the .gitmodules file", err)
+	}
+	for _, s := range m.Submodules {
+		modules[s.Path] = s.Name
+	}
+
+	return modules, nil
+}
+
+// submodule returns the walker and commit of the submodule at the path of
+// the repo.  Like git, the objects of a submodule are looked for in its
+// modules directory.
+func (w *treeWalker) submodule(path string, hash plumbing.Hash) (*treeWalker, *object.Commit, error) {
+	name, found := w.modules[path]
+	if !found {
+		return nil, nil, fmt.Errorf("%w: '%s' is not listed in the .gitmodules file", ErrSubmoduleMissing, path)
+	}
+
+	s, err := w.storer.Module(name)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: unable to open the submodule '%s': %s", ErrSubmoduleMissing, path, err)
+	}
+
+	commit, err := object.GetCommit(s, hash)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: commit %s of the submodule '%s': %s", ErrSubmoduleMissing, hash, path, err)
+	}
+
+	modules, err := readModules(commit)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: in the submodule '%s'", err, path)
+	}
+
+	sub := &treeWalker{
+		storer:     s,
+		commit:     commit,
+		submodules: true,
+		modules:    modules,
+		archive:    w.archive,
+		prefix:     w.prefix,
+	}
+	if w.lfs != nil {
+		if sub.lfs, err = lfsObjects(s); err != nil {
+			return nil, nil, fmt.Errorf("%w: in the submodule '%s'", err, path)
+		}
+	}
+	return sub, commit, nil
+}
+
+// addFiles adds the files below the prefix along with any directories above
+// them that are missing.  A file replaces an entry with the same name.
+func addFiles(entries []archiveEntry, prefix string, files []ArchiveFile, a *Archive) ([]archiveEntry, error) {
+	if len(files) == 0 {
+		return entries, nil
+	}
+
+	index := make(map[string]int, len(entries))
+	for i := range entries {
+		index[entries[i].name] = i
+	}
+
+	for _, f := range files {
+		if f.Name == "" || f.Name != path.Clean(f.Name) || path.IsAbs(f.Name) ||
+			f.Name == ".." || strings.HasPrefix(f.Name, "../") {
+			return nil, fmt.Errorf("%w: '%s'", ErrArchiveFileName, f.Name)
+		}
+
+		for dir := path.Dir(f.Name); dir != "."; dir = path.Dir(dir) {
+			name := prefix + dir + "/"
+			if _, found := index[name]; !found {
+				index[name] = len(entries)
+				entries = append(entries, archiveEntry{name: name, mode: filemode.Dir})
+			}
+		}
+
+		e := archiveEntry{name: prefix + f.Name, mode: filemode.Regular, data: f.Data}
+		if f.Executable {
+			e.mode = filemode.Executable
+		}
+		if i, found := index[e.name]; found {
+			entries[i] = e
+		} else {
+			index[e.name] = len(entries)
+			entries = append(entries, e)
+		}
+		a.Added = append(a.Added, f.Name)
+	}
+
+	sortEntries(entries)
+	return entries, nil
+}
+
+// sortEntries sorts the entries by name.  Directory names end with a slash so
+// each directory is placed before its contents.
+func sortEntries(entries []archiveEntry) {
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].name < entries[j].name
+	})
+}
+
+// appendEntries walks the tree depth first, adding each entry.  The dir is
+// the path of the tree in the repo and the attrs are those of the
+// .gitattributes files above it.
+func (w *treeWalker) appendEntries(entries []archiveEntry, tree *object.Tree, dir, prefix string,
+	attrs []gitattributes.MatchAttribute) ([]archiveEntry, error) {
+	more, err := readAttributes(tree, dir)
+	if err != nil {
+		return nil, err
+	}
+	// The deeper files take priority, and the slice is shared by the
+	// sibling directories so it is copied first.
+	attrs = append(attrs[:len(attrs):len(attrs)], more...)
+
+	for i := range tree.Entries {
+		e := &tree.Entries[i]
+		name := prefix + e.Name
+
+		found := pathAttributes(attrs, splitPath(dir+e.Name))
+		if isSet(found, attrExportIgnore) {
+			excluded := strings.TrimPrefix(name, w.prefix)
+			if e.Mode == filemode.Dir || e.Mode == filemode.Submodule {
+				excluded += "/"
+			}
+			w.archive.Excluded = append(w.archive.Excluded, excluded)
+			continue
+		}
+
+		switch e.Mode {
+		case filemode.Dir:
+			sub, err := tree.Tree(e.Name)
+			if err != nil {
+				return nil, fmt.Errorf("%w: unable to read the directory '%s'", err, name)
+			}
+			entries = append(entries, archiveEntry{name: name + "/", mode: e.Mode})
+			if entries, err = w.appendEntries(entries, sub, dir+e.Name+"/", name+"/", attrs); err != nil {
+				return nil, err
+			}
+			continue
+		case filemode.Submodule:
+			entries = append(entries, archiveEntry{name: name + "/", mode: e.Mode})
+			if !w.submodules {
+				continue
+			}
+
+			sw, commit, err := w.submodule(dir+e.Name, e.Hash)
+			if err != nil {
+				return nil, err
+			}
+			sub, err := commit.Tree()
+			if err != nil {
+				return nil, fmt.Errorf("%w: commit.Tree() error for the submodule '%s'", err, dir+e.Name)
+			}
+			if entries, err = sw.appendEntries(entries, sub, "", name+"/", nil); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		file, err := tree.TreeEntryFile(e)
+		if err != nil {
+			return nil, fmt.Errorf("%w: unable to read the file '%s'", err, name)
+		}
+		data, err := readBlob(&file.Blob)
+		if err != nil {
+			return nil, fmt.Errorf("%w: unable to read the file '%s'", err, name)
+		}
+		if w.lfs != nil && hasValue(found, attrFilter, "lfs") && e.Mode != filemode.Symlink {
+			// Like git lfs, a file that isn't a pointer is archived as is.
+			if pointer, ok := parseLFSPointer(data); ok {
+				if data, err = readLFSObject(w.lfs, pointer, dir+e.Name); err != nil {
+					return nil, err
+				}
+				w.archive.LFS = append(w.archive.LFS, strings.TrimPrefix(name, w.prefix))
+			}
+		}
+		if isSet(found, attrExportSubst) && e.Mode != filemode.Symlink {
+			data = substitute(data, w.commit)
+			w.archive.Substituted = append(w.archive.Substituted, strings.TrimPrefix(name, w.prefix))
+		}
+		entries = append(entries, archiveEntry{name: name, mode: e.Mode, data: data})
+	}
+
+	return entries, nil
+}
+
+func readBlob(b *object.Blob) ([]byte, error) {
+	r, err := b.Reader()
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	return io.ReadAll(r)
+}
+
+// writeArchive writes the entries in the format.  Like git archive, the
+// commit hash is stored as the archive comment and every entry uses the
+// commit time, so nothing depends on when or where the archive is built.
+func writeArchive(w io.Writer, format Format, commit *object.Commit, entries []archiveEntry) error {
+	when := commit.Committer.When.UTC().Truncate(time.Second)
+
+	if !format.IsTar() {
+		return writeZip(w, format, commit.Hash.String(), when, entries)
+	}
+
+	cw, err := format.compressor(w)
+	if err != nil {
+		return err
+	}
+	if err = writeTar(cw, commit.Hash.String(), when, entries); err != nil {
+		return err
+	}
+	return cw.Close()
+}
+
+// tarMode returns the permissions git archive uses with the default
+// tar.umask of 0002.
+func tarMode(e *archiveEntry) int64 {
+	switch {
+	case e.isDir(), e.mode == filemode.Executable:
+		return 0775
+	case e.mode == filemode.Symlink:
+		return 0777
+	}
+	return 0664
+}
+
+func writeTar(w io.Writer, comment string, when time.Time, entries []archiveEntry) error {
+	tw := tar.NewWriter(w)
+
+	err := tw.WriteHeader(&tar.Header{
+		Typeflag:   tar.TypeXGlobalHeader,
+		Name:       "pax_global_header",
+		PAXRecords: map[string]string{"comment": comment},
+	})
+	if err != nil {
+		return err
+	}
+
+	for i := range entries {
+		e := &entries[i]
+		hdr := &tar.Header{
+			Name:    e.name,
+			Mode:    tarMode(e),
+			ModTime: when,
+			Uid:     0,
+			Gid:     0,
+			Uname:   "root",
+			Gname:   "root",
+			Format:  tar.FormatPAX,
+		}
+
+		switch {
+		case e.isDir():
+			hdr.Typeflag = tar.TypeDir
+		case e.mode == filemode.Symlink:
+			hdr.Typeflag = tar.TypeSymlink
+			hdr.Linkname = string(e.data)
+		default:
+			hdr.Typeflag = tar.TypeReg
+			hdr.Size = int64(len(e.data))
+		}
+
+		if err = tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		if hdr.Typeflag == tar.TypeReg {
+			if _, err = tw.Write(e.data); err != nil {
+				return err
+			}
+		}
+	}
+
+	return tw.Close()
+}
+
+// zipMode returns the file mode stored in the zip for the entry.
+func zipMode(e *archiveEntry) os.FileMode {
+	switch {
+	case e.isDir():
+		return os.ModeDir | 0755
+	case e.mode == filemode.Symlink:
+		return os.ModeSymlink | 0777
+	case e.mode == filemode.Executable:
+		return 0755
+	}
+	return 0644
+}
+
+// writeZip writes the zip archive.  The only extra field of each entry is the
+// extended timestamp holding the commit time.
+func writeZip(w io.Writer, format Format, comment string, when time.Time, entries []archiveEntry) error {
+	zw := zip.NewWriter(w)
+	zw.RegisterCompressor(zip.Deflate, format.deflate)
+	if err := zw.SetComment(comment); err != nil {
+		return err
+	}
+
+	for i := range entries {
+		e := &entries[i]
+		hdr := &zip.FileHeader{
+			Name:     e.name,
+			Method:   zip.Deflate,
+			Modified: when,
+		}
+		hdr.SetMode(zipMode(e))
+		if e.isDir() {
+			hdr.Method = zip.Store
+		}
+
+		fw, err := zw.CreateHeader(hdr)
+		if err != nil {
+			return err
+		}
+		if !e.isDir() {
+			// Symlinks hold the target as the contents.
+			if _, err = fw.Write(e.data); err != nil {
+				return err
+			}
+		}
+	}
+
+	return zw.Close()
+}
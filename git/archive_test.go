@@ -0,0 +1,640 @@
+// SPDX-FileCopyrightText: 2021 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+package git
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/bzip2"
+	"compress/gzip"
+	"errors"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/storage/memory"
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ulikunitz/xz"
+)
+
+// testFile is a file committed to the test repo.
+type testFile struct {
+	mode filemode.FileMode
+	data string
+}
+
+var testSig = object.Signature{
+	Name:  "Test",
+	Email: "test@example.com",
+	When:  time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC),
+}
+
+// newTestRepo creates an in memory repo with a single commit holding the
+// files, tagged with the tag.
+func newTestRepo(t *testing.T, tag string, files map[string]testFile) *Git {
+	t.Helper()
+
+	repo, err := git.Init(memory.NewStorage(), nil)
+	require.NoError(t, err)
+
+	hash := storeCommit(t, repo, files)
+
+	_, err = repo.CreateTag(tag, hash, &git.CreateTagOptions{
+		Tagger:  &testSig,
+		Message: "Releasing: " + tag,
+	})
+	require.NoError(t, err)
+
+	return &Git{repo: repo}
+}
+
+// storeCommit stores a commit holding the files and returns its hash.
+func storeCommit(t *testing.T, repo *git.Repository, files map[string]testFile) plumbing.Hash {
+	t.Helper()
+
+	commit := &object.Commit{
+		Author:    testSig,
+		Committer: testSig,
+		Message:   "test\n",
+		TreeHash:  storeTree(t, repo, "", files),
+	}
+	obj := repo.Storer.NewEncodedObject()
+	require.NoError(t, commit.Encode(obj))
+	hash, err := repo.Storer.SetEncodedObject(obj)
+	require.NoError(t, err)
+
+	return hash
+}
+
+// moduleRepo returns the repo in the modules storage of the submodule.
+func moduleRepo(t *testing.T, repo *git.Repository, name string) *git.Repository {
+	t.Helper()
+
+	s, err := repo.Storer.Module(name)
+	require.NoError(t, err)
+	sub, err := git.Init(s, nil)
+	require.NoError(t, err)
+
+	return sub
+}
+
+// storeTree stores the files below the dir as trees and blobs and returns
+// the hash of the tree.
+func storeTree(t *testing.T, repo *git.Repository, dir string, files map[string]testFile) plumbing.Hash {
+	t.Helper()
+
+	tree := &object.Tree{}
+	subdirs := map[string]bool{}
+	for name, f := range files {
+		if !strings.HasPrefix(name, dir) {
+			continue
+		}
+		rest := strings.TrimPrefix(name, dir)
+		if i := strings.Index(rest, "/"); i >= 0 {
+			sub := rest[:i]
+			if !subdirs[sub] {
+				subdirs[sub] = true
+				tree.Entries = append(tree.Entries, object.TreeEntry{
+					Name: sub,
+					Mode: filemode.Dir,
+					Hash: storeTree(t, repo, dir+sub+"/", files),
+				})
+			}
+			continue
+		}
+
+		if f.mode == filemode.Submodule {
+			tree.Entries = append(tree.Entries, object.TreeEntry{
+				Name: rest,
+				Mode: f.mode,
+				Hash: plumbing.NewHash(f.data),
+			})
+			continue
+		}
+
+		blob := repo.Storer.NewEncodedObject()
+		blob.SetType(plumbing.BlobObject)
+		w, err := blob.Writer()
+		require.NoError(t, err)
+		_, err = io.WriteString(w, f.data)
+		require.NoError(t, err)
+		require.NoError(t, w.Close())
+		hash, err := repo.Storer.SetEncodedObject(blob)
+		require.NoError(t, err)
+
+		tree.Entries = append(tree.Entries, object.TreeEntry{
+			Name: rest,
+			Mode: f.mode,
+			Hash: hash,
+		})
+	}
+
+	// Git sorts directories as if the name ends with a slash.
+	key := func(e object.TreeEntry) string {
+		if e.Mode == filemode.Dir {
+			return e.Name + "/"
+		}
+		return e.Name
+	}
+	sort.Slice(tree.Entries, func(i, j int) bool {
+		return key(tree.Entries[i]) < key(tree.Entries[j])
+	})
+
+	obj := repo.Storer.NewEncodedObject()
+	require.NoError(t, tree.Encode(obj))
+	hash, err := repo.Storer.SetEncodedObject(obj)
+	require.NoError(t, err)
+
+	return hash
+}
+
+// archived is an entry read back from an archive.
+type archived struct {
+	mode os.FileMode
+	data string
+}
+
+// decompress returns a reader of the tar inside the compressed file.
+func decompress(t *testing.T, f io.Reader, format string) io.Reader {
+	t.Helper()
+
+	switch format {
+	case FormatTarGz:
+		gz, err := gzip.NewReader(f)
+		require.NoError(t, err)
+		return gz
+	case FormatTarXz:
+		xr, err := xz.NewReader(f)
+		require.NoError(t, err)
+		return xr
+	case FormatTarZst:
+		zr, err := zstd.NewReader(f)
+		require.NoError(t, err)
+		t.Cleanup(zr.Close)
+		return zr
+	case FormatTarBz2:
+		return bzip2.NewReader(f)
+	}
+
+	return f
+}
+
+func readTar(t *testing.T, file, format string) (map[string]archived, string) {
+	t.Helper()
+
+	f, err := os.Open(file)
+	require.NoError(t, err)
+	defer f.Close()
+
+	var comment string
+	got := map[string]archived{}
+	tr := tar.NewReader(decompress(t, f, format))
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		require.NoError(t, err)
+
+		if hdr.Typeflag == tar.TypeXGlobalHeader {
+			comment = hdr.PAXRecords["comment"]
+			continue
+		}
+
+		data := hdr.Linkname
+		if hdr.Typeflag == tar.TypeReg {
+			buf, err := io.ReadAll(tr)
+			require.NoError(t, err)
+			data = string(buf)
+		}
+		got[hdr.Name] = archived{mode: hdr.FileInfo().Mode(), data: data}
+	}
+
+	return got, comment
+}
+
+func readZip(t *testing.T, file string) (map[string]archived, string) {
+	t.Helper()
+
+	zr, err := zip.OpenReader(file)
+	require.NoError(t, err)
+	defer zr.Close()
+
+	got := map[string]archived{}
+	for _, zf := range zr.File {
+		r, err := zf.Open()
+		require.NoError(t, err)
+		buf, err := io.ReadAll(r)
+		require.NoError(t, err)
+		r.Close()
+
+		got[zf.Name] = archived{mode: zf.Mode(), data: string(buf)}
+	}
+
+	return got, zr.Comment
+}
+
+func TestCreateArchive(t *testing.T) {
+	files := map[string]testFile{
+		"README.md":         {mode: filemode.Regular, data: "readme\n"},
+		"build.sh":          {mode: filemode.Executable, data: "#!/bin/sh\n"},
+		"link":              {mode: filemode.Symlink, data: "README.md"},
+		"sub/lib/lib.c":     {mode: filemode.Regular, data: "int x;\n"},
+		"sub/lib/configure": {mode: filemode.Executable, data: "#!/bin/sh\n"},
+		"vendor/dep":        {mode: filemode.Submodule, data: "0123456789012345678901234567890123456789"},
+	}
+
+	tarEntries := map[string]archived{
+		"foo-1.2.3/":                  {mode: os.ModeDir | 0775},
+		"foo-1.2.3/README.md":         {mode: 0664, data: "readme\n"},
+		"foo-1.2.3/build.sh":          {mode: 0775, data: "#!/bin/sh\n"},
+		"foo-1.2.3/link":              {mode: os.ModeSymlink | 0777, data: "README.md"},
+		"foo-1.2.3/sub/":              {mode: os.ModeDir | 0775},
+		"foo-1.2.3/sub/lib/":          {mode: os.ModeDir | 0775},
+		"foo-1.2.3/sub/lib/configure": {mode: 0775, data: "#!/bin/sh\n"},
+		"foo-1.2.3/sub/lib/lib.c":     {mode: 0664, data: "int x;\n"},
+		"foo-1.2.3/vendor/":           {mode: os.ModeDir | 0775},
+		"foo-1.2.3/vendor/dep/":       {mode: os.ModeDir | 0775},
+	}
+
+	tests := []struct {
+		description string
+		subdir      string
+		format      Format
+		expected    map[string]archived
+		expectedErr error
+	}{
+		{
+			description: "tar.gz",
+			format:      Format{Name: FormatTarGz},
+			expected:    tarEntries,
+		}, {
+			description: "tar",
+			format:      Format{Name: FormatTar},
+			expected:    tarEntries,
+		}, {
+			description: "tar.xz",
+			format:      Format{Name: FormatTarXz, Level: 9},
+			expected:    tarEntries,
+		}, {
+			description: "tar.zst",
+			format:      Format{Name: FormatTarZst, Level: 22},
+			expected:    tarEntries,
+		}, {
+			description: "tar.bz2",
+			format:      Format{Name: FormatTarBz2, Level: 1},
+			expected:    tarEntries,
+		}, {
+			description: "zip",
+			format:      Format{Name: FormatZip, Level: 9},
+			expected: map[string]archived{
+				"foo-1.2.3/":                  {mode: os.ModeDir | 0755},
+				"foo-1.2.3/README.md":         {mode: 0644, data: "readme\n"},
+				"foo-1.2.3/build.sh":          {mode: 0755, data: "#!/bin/sh\n"},
+				"foo-1.2.3/link":              {mode: os.ModeSymlink | 0777, data: "README.md"},
+				"foo-1.2.3/sub/":              {mode: os.ModeDir | 0755},
+				"foo-1.2.3/sub/lib/":          {mode: os.ModeDir | 0755},
+				"foo-1.2.3/sub/lib/configure": {mode: 0755, data: "#!/bin/sh\n"},
+				"foo-1.2.3/sub/lib/lib.c":     {mode: 0644, data: "int x;\n"},
+				"foo-1.2.3/vendor/":           {mode: os.ModeDir | 0755},
+				"foo-1.2.3/vendor/dep/":       {mode: os.ModeDir | 0755},
+			},
+		}, {
+			description: "subdir",
+			subdir:      "sub/lib",
+			format:      Format{Name: FormatTarGz},
+			expected: map[string]archived{
+				"foo-1.2.3/":          {mode: os.ModeDir | 0775},
+				"foo-1.2.3/configure": {mode: 0775, data: "#!/bin/sh\n"},
+				"foo-1.2.3/lib.c":     {mode: 0664, data: "int x;\n"},
+			},
+		}, {
+			description: "unknown format",
+			format:      Format{Name: "rar"},
+			expectedErr: ErrArchiveFormat,
+		}, {
+			description: "level out of range",
+			format:      Format{Name: FormatTarGz, Level: 10},
+			expectedErr: ErrArchiveLevel,
+		}, {
+			description: "missing subdir",
+			subdir:      "missing",
+			format:      Format{Name: FormatZip},
+			expectedErr: object.ErrDirectoryNotFound,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			assert := assert.New(t)
+
+			g := newTestRepo(t, "v1.2.3", files)
+			dir := t.TempDir()
+
+			a, err := g.CreateArchive("foo-1.2.3", "v1.2.3", tc.subdir, tc.format, dir, nil)
+			if tc.expectedErr != nil {
+				assert.ErrorIs(err, tc.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			file := a.File
+			assert.Equal(dir+"/foo-1.2.3."+tc.format.Name, file)
+
+			hash, err := g.ResolveCommit("v1.2.3")
+			require.NoError(t, err)
+
+			var entries map[string]archived
+			var comment string
+			if tc.format.IsTar() {
+				entries, comment = readTar(t, file, tc.format.Name)
+			} else {
+				entries, comment = readZip(t, file)
+			}
+			assert.Equal(tc.expected, entries)
+			assert.Equal(hash, comment)
+		})
+	}
+}
+
+func TestCreateArchiveReproducible(t *testing.T) {
+	files := map[string]testFile{
+		"b.txt":   {mode: filemode.Regular, data: "b\n"},
+		"a/z.txt": {mode: filemode.Regular, data: "z\n"},
+		"a.txt":   {mode: filemode.Executable, data: "a\n"},
+	}
+
+	formats := []Format{
+		{Name: FormatTar},
+		{Name: FormatTarGz},
+		{Name: FormatTarXz, Level: 1},
+		{Name: FormatTarZst, Level: 19},
+		{Name: FormatTarBz2},
+		{Name: FormatZip},
+	}
+	for _, format := range formats {
+		t.Run(format.String(), func(t *testing.T) {
+			assert := assert.New(t)
+
+			// Separate repos, so only the contents and commit are shared.
+			first, err := newTestRepo(t, "v1.0.0", files).CreateArchive("foo-1.0.0", "v1.0.0", "", format, t.TempDir(), nil)
+			require.NoError(t, err)
+			second, err := newTestRepo(t, "v1.0.0", files).CreateArchive("foo-1.0.0", "v1.0.0", "", format, t.TempDir(), nil)
+			require.NoError(t, err)
+
+			a, err := os.ReadFile(first.File)
+			require.NoError(t, err)
+			b, err := os.ReadFile(second.File)
+			require.NoError(t, err)
+			assert.Equal(a, b)
+		})
+	}
+}
+
+func TestCreateArchiveHeaders(t *testing.T) {
+	assert := assert.New(t)
+
+	g := newTestRepo(t, "v1.0.0", map[string]testFile{
+		"b.txt":   {mode: filemode.Regular, data: "b\n"},
+		"a/z.txt": {mode: filemode.Regular, data: "z\n"},
+		"a.txt":   {mode: filemode.Regular, data: "a\n"},
+	})
+	when := time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	a, err := g.CreateArchive("foo", "v1.0.0", "", Format{Name: FormatTarGz}, t.TempDir(), nil)
+	require.NoError(t, err)
+
+	f, err := os.Open(a.File)
+	require.NoError(t, err)
+	defer f.Close()
+
+	gz, err := gzip.NewReader(f)
+	require.NoError(t, err)
+	assert.Empty(gz.Name)
+	assert.True(gz.ModTime.IsZero())
+	assert.Equal(byte(gzipOSUnix), gz.OS)
+
+	var names []string
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		require.NoError(t, err)
+		if hdr.Typeflag == tar.TypeXGlobalHeader {
+			continue
+		}
+
+		names = append(names, hdr.Name)
+		assert.Equal(0, hdr.Uid)
+		assert.Equal(0, hdr.Gid)
+		assert.Equal("root", hdr.Uname)
+		assert.Equal("root", hdr.Gname)
+		assert.True(when.Equal(hdr.ModTime), hdr.Name)
+	}
+	assert.Equal([]string{"foo/", "foo/a.txt", "foo/a/", "foo/a/z.txt", "foo/b.txt"}, names)
+
+	a, err = g.CreateArchive("foo", "v1.0.0", "", Format{Name: FormatZip}, t.TempDir(), nil)
+	require.NoError(t, err)
+
+	zr, err := zip.OpenReader(a.File)
+	require.NoError(t, err)
+	defer zr.Close()
+
+	names = nil
+	for _, zf := range zr.File {
+		names = append(names, zf.Name)
+		assert.True(when.Equal(zf.Modified), zf.Name)
+	}
+	assert.Equal([]string{"foo/", "foo/a.txt", "foo/a/", "foo/a/z.txt", "foo/b.txt"}, names)
+}
+
+func TestCreateArchiveSubmodules(t *testing.T) {
+	const gitmodules = `[submodule "dep"]
+	path = vendor/dep
+	url = https://example.com/dep.git
+`
+	const nested = `[submodule "inner"]
+	path = inner
+	url = https://example.com/inner.git
+`
+
+	tests := []struct {
+		description string
+		missing     bool
+		unlisted    bool
+		expected    map[string]archived
+		expectedErr error
+	}{
+		{
+			description: "recursive",
+			expected: map[string]archived{
+				"foo/":                         {mode: os.ModeDir | 0775},
+				"foo/.gitmodules":              {mode: 0664, data: gitmodules},
+				"foo/main.c":                   {mode: 0664, data: "int main;\n"},
+				"foo/vendor/":                  {mode: os.ModeDir | 0775},
+				"foo/vendor/dep/":              {mode: os.ModeDir | 0775},
+				"foo/vendor/dep/.gitmodules":   {mode: 0664, data: nested},
+				"foo/vendor/dep/dep.c":         {mode: 0664, data: "int dep;\n"},
+				"foo/vendor/dep/inner/":        {mode: os.ModeDir | 0775},
+				"foo/vendor/dep/inner/inner.c": {mode: 0664, data: "int inner;\n"},
+			},
+		}, {
+			description: "commit missing",
+			missing:     true,
+			expectedErr: ErrSubmoduleMissing,
+		}, {
+			description: "not in .gitmodules",
+			unlisted:    true,
+			expectedErr: ErrSubmoduleMissing,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			assert := assert.New(t)
+
+			repo, err := git.Init(memory.NewStorage(), nil)
+			require.NoError(t, err)
+			g := &Git{repo: repo, opts: Options{Submodules: true}}
+
+			dep := moduleRepo(t, repo, "dep")
+			inner := storeCommit(t, moduleRepo(t, dep, "inner"), map[string]testFile{
+				"inner.c": {mode: filemode.Regular, data: "int inner;\n"},
+			})
+			depHash := plumbing.NewHash("0123456789012345678901234567890123456789")
+			if !tc.missing {
+				depHash = storeCommit(t, dep, map[string]testFile{
+					".gitmodules": {mode: filemode.Regular, data: nested},
+					"dep.c":       {mode: filemode.Regular, data: "int dep;\n"},
+					"inner":       {mode: filemode.Submodule, data: inner.String()},
+				})
+			}
+
+			files := map[string]testFile{
+				"main.c":     {mode: filemode.Regular, data: "int main;\n"},
+				"vendor/dep": {mode: filemode.Submodule, data: depHash.String()},
+			}
+			if !tc.unlisted {
+				files[".gitmodules"] = testFile{mode: filemode.Regular, data: gitmodules}
+			}
+			hash := storeCommit(t, repo, files)
+			_, err = repo.CreateTag("v1.0.0", hash, &git.CreateTagOptions{
+				Tagger:  &testSig,
+				Message: "Releasing: v1.0.0",
+			})
+			require.NoError(t, err)
+
+			a, err := g.CreateArchive("foo", "v1.0.0", "", Format{Name: FormatTarGz}, t.TempDir(), nil)
+			if tc.expectedErr != nil {
+				assert.ErrorIs(err, tc.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+
+			entries, _ := readTar(t, a.File, FormatTarGz)
+			assert.Equal(tc.expected, entries)
+		})
+	}
+}
+
+func TestCreateArchiveAddedFiles(t *testing.T) {
+	files := map[string]testFile{
+		"configure": {mode: filemode.Regular, data: "stub\n"},
+		"main.c":    {mode: filemode.Regular, data: "int main;\n"},
+	}
+	extra := []ArchiveFile{
+		{Name: "VERSION", Data: []byte("1.0.0\n")},
+		{Name: "configure", Data: []byte("#!/bin/sh\n"), Executable: true},
+		{Name: "build-aux/config.guess", Data: []byte("guess\n"), Executable: true},
+	}
+
+	tests := []struct {
+		description string
+		format      Format
+		extra       []ArchiveFile
+		expected    map[string]archived
+		expectedErr error
+	}{
+		{
+			description: "tar",
+			format:      Format{Name: FormatTar},
+			extra:       extra,
+			expected: map[string]archived{
+				"foo/":                       {mode: os.ModeDir | 0775},
+				"foo/VERSION":                {mode: 0664, data: "1.0.0\n"},
+				"foo/build-aux/":             {mode: os.ModeDir | 0775},
+				"foo/build-aux/config.guess": {mode: 0775, data: "guess\n"},
+				"foo/configure":              {mode: 0775, data: "#!/bin/sh\n"},
+				"foo/main.c":                 {mode: 0664, data: "int main;\n"},
+			},
+		}, {
+			description: "zip",
+			format:      Format{Name: FormatZip},
+			extra:       extra,
+			expected: map[string]archived{
+				"foo/":                       {mode: os.ModeDir | 0755},
+				"foo/VERSION":                {mode: 0644, data: "1.0.0\n"},
+				"foo/build-aux/":             {mode: os.ModeDir | 0755},
+				"foo/build-aux/config.guess": {mode: 0755, data: "guess\n"},
+				"foo/configure":              {mode: 0755, data: "#!/bin/sh\n"},
+				"foo/main.c":                 {mode: 0644, data: "int main;\n"},
+			},
+		}, {
+			description: "outside the archive",
+			format:      Format{Name: FormatTar},
+			extra:       []ArchiveFile{{Name: "../VERSION"}},
+			expectedErr: ErrArchiveFileName,
+		}, {
+			description: "absolute",
+			format:      Format{Name: FormatTar},
+			extra:       []ArchiveFile{{Name: "/VERSION"}},
+			expectedErr: ErrArchiveFileName,
+		}, {
+			description: "not clean",
+			format:      Format{Name: FormatTar},
+			extra:       []ArchiveFile{{Name: "a//VERSION"}},
+			expectedErr: ErrArchiveFileName,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			assert := assert.New(t)
+
+			a, err := newTestRepo(t, "v1.0.0", files).CreateArchive("foo", "v1.0.0", "", tc.format, t.TempDir(), tc.extra)
+			if tc.expectedErr != nil {
+				assert.ErrorIs(err, tc.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal([]string{"VERSION", "configure", "build-aux/config.guess"}, a.Added)
+
+			var entries map[string]archived
+			if tc.format.IsTar() {
+				entries, _ = readTar(t, a.File, tc.format.Name)
+			} else {
+				entries, _ = readZip(t, a.File)
+			}
+			assert.Equal(tc.expected, entries)
+
+			// The added files don't change the reproducibility.
+			again, err := newTestRepo(t, "v1.0.0", files).CreateArchive("foo", "v1.0.0", "", tc.format, t.TempDir(), tc.extra)
+			require.NoError(t, err)
+			first, err := os.ReadFile(a.File)
+			require.NoError(t, err)
+			second, err := os.ReadFile(again.File)
+			require.NoError(t, err)
+			assert.Equal(first, second)
+		})
+	}
+}
@@ -0,0 +1,254 @@
+// SPDX-FileCopyrightText: 2021 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+package git
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/go-git/go-git/v5/plumbing/format/gitattributes"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+const (
+	attrExportIgnore = "export-ignore"
+	attrExportSubst  = "export-subst"
+	attrFilter       = "filter"
+
+	// abbrevLen is the length of the abbreviated hashes, which is the
+	// shortest length git uses.
+	abbrevLen = 7
+)
+
+// readAttributes returns the attributes of the .gitattributes file in the
+// tree, which is at the dir of the repo.  Like git, macros may only be
+// defined at the top of the repo.
+func readAttributes(tree *object.Tree, dir string) ([]gitattributes.MatchAttribute, error) {
+	f, err := tree.File(".gitattributes")
+	if err != nil {
+		if errors.Is(err, object.ErrFileNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("%w: unable to read the '%s.gitattributes' file", err, dir)
+	}
+
+	r, err := f.Reader()
+	if err != nil {
+		return nil, fmt.Errorf("%w: unable to read the '%s.gitattributes' file", err, dir)
+	}
+	defer r.Close()
+
+	attrs, err := gitattributes.ReadAttributes(r, splitPath(dir), dir == "")
+	if err != nil {
+		return nil, fmt.Errorf("%w: unable to parse the '%s.gitattributes' file", err, dir)
+	}
+
+	return attrs, nil
+}
+
+// parentAttributes returns the attributes of the .gitattributes files of the
+// directories above the subdir, which apply to it as well.
+func parentAttributes(root *object.Tree, subdir string) ([]gitattributes.MatchAttribute, error) {
+	var attrs []gitattributes.MatchAttribute
+
+	tree := root
+	dir := ""
+	for _, part := range splitPath(subdir) {
+		more, err := readAttributes(tree, dir)
+		if err != nil {
+			return nil, err
+		}
+		attrs = append(attrs, more...)
+
+		if tree, err = tree.Tree(part); err != nil {
+			return nil, fmt.Errorf("%w: unable to read the directory '%s%s'", err, dir, part)
+		}
+		dir += part + "/"
+	}
+
+	return attrs, nil
+}
+
+// pathAttributes returns the attributes of the path, like export-ignore and
+// export-subst, keyed by name.  The go-git matcher lets a less specific line
+// override a more specific one, so the lines are checked here from the most
+// specific down and the first line to mention an attribute decides it.
+// Within a line the later attributes win, and set macros are expanded.
+func pathAttributes(attrs []gitattributes.MatchAttribute, path []string) map[string]gitattributes.Attribute {
+	macros := map[string][]gitattributes.Attribute{}
+	for _, a := range attrs {
+		if a.Pattern == nil {
+			macros[a.Name] = a.Attributes
+		}
+	}
+
+	found := map[string]gitattributes.Attribute{}
+	for i := len(attrs) - 1; i >= 0; i-- {
+		if attrs[i].Pattern == nil || !attrs[i].Pattern.Match(path) {
+			continue
+		}
+
+		line := map[string]gitattributes.Attribute{}
+		for _, a := range attrs[i].Attributes {
+			if a.IsSet() {
+				for _, m := range macros[a.Name()] {
+					line[m.Name()] = m
+				}
+			}
+			line[a.Name()] = a
+		}
+		for name, a := range line {
+			if _, done := found[name]; !done {
+				found[name] = a
+			}
+		}
+	}
+
+	return found
+}
+
+// isSet returns true if the attribute is set.
+func isSet(found map[string]gitattributes.Attribute, name string) bool {
+	a, ok := found[name]
+	return ok && a.IsSet()
+}
+
+// hasValue returns true if the attribute is set to the value.
+func hasValue(found map[string]gitattributes.Attribute, name, value string) bool {
+	a, ok := found[name]
+	return ok && a.IsValueSet() && a.Value() == value
+}
+
+// splitPath splits the slash separated path into its parts.
+func splitPath(path string) []string {
+	return strings.FieldsFunc(path, func(r rune) bool {
+		return r == '/'
+	})
+}
+
+// substitute expands the $Format:...$ placeholders in the data like git
+// archive does for files with the export-subst attribute.
+func substitute(data []byte, commit *object.Commit) []byte {
+	const start = "$Format:"
+
+	var buf bytes.Buffer
+	for {
+		i := bytes.Index(data, []byte(start))
+		if i < 0 {
+			break
+		}
+		j := bytes.IndexByte(data[i+len(start):], '$')
+		if j < 0 {
+			break
+		}
+
+		buf.Write(data[:i])
+		buf.WriteString(prettyFormat(string(data[i+len(start):i+len(start)+j]), commit))
+		data = data[i+len(start)+j+1:]
+	}
+	buf.Write(data)
+
+	return buf.Bytes()
+}
+
+// prettyFormat expands the git log pretty format placeholders that depend
+// only on the commit.  The reference names are left out so the archives of
+// a commit are always the same, and unknown placeholders are kept as is.
+func prettyFormat(format string, commit *object.Commit) string {
+	var sb strings.Builder
+
+	for len(format) > 0 {
+		i := strings.IndexByte(format, '%')
+		if i < 0 || i == len(format)-1 {
+			sb.WriteString(format)
+			break
+		}
+		sb.WriteString(format[:i])
+		format = format[i+1:]
+
+		s, n := placeholder(format, commit)
+		if n == 0 {
+			sb.WriteByte('%')
+			continue
+		}
+		sb.WriteString(s)
+		format = format[n:]
+	}
+
+	return sb.String()
+}
+
+// placeholder returns the expansion of the placeholder at the start of the
+// format along with its length, or 0 if it is unknown.
+func placeholder(format string, commit *object.Commit) (string, int) {
+	switch format[0] {
+	case 'H':
+		return commit.Hash.String(), 1
+	case 'h':
+		return commit.Hash.String()[:abbrevLen], 1
+	case 'T':
+		return commit.TreeHash.String(), 1
+	case 't':
+		return commit.TreeHash.String()[:abbrevLen], 1
+	case 'P', 'p':
+		parents := make([]string, len(commit.ParentHashes))
+		for i, h := range commit.ParentHashes {
+			parents[i] = h.String()
+			if format[0] == 'p' {
+				parents[i] = parents[i][:abbrevLen]
+			}
+		}
+		return strings.Join(parents, " "), 1
+	case 's':
+		subject, _, _ := strings.Cut(commit.Message, "\n\n")
+		return strings.Join(strings.Fields(subject), " "), 1
+	case 'b':
+		_, body, _ := strings.Cut(commit.Message, "\n\n")
+		return strings.TrimLeft(body, "\n"), 1
+	case 'B':
+		return commit.Message, 1
+	case 'n':
+		return "\n", 1
+	case '%':
+		return "%", 1
+	case 'a', 'c':
+		if len(format) < 2 {
+			return "", 0
+		}
+		sig := commit.Author
+		if format[0] == 'c' {
+			sig = commit.Committer
+		}
+		if s, found := signatureField(sig, format[1]); found {
+			return s, 2
+		}
+	}
+
+	return "", 0
+}
+
+// signatureField returns the author or committer field of the placeholder.
+// The dates use the time zone recorded in the commit.
+func signatureField(sig object.Signature, field byte) (string, bool) {
+	switch field {
+	case 'n':
+		return sig.Name, true
+	case 'e':
+		return sig.Email, true
+	case 'd':
+		return sig.When.Format("Mon Jan 2 15:04:05 2006 -0700"), true
+	case 'D':
+		return sig.When.Format("Mon, 2 Jan 2006 15:04:05 -0700"), true
+	case 'i':
+		return sig.When.Format("2006-01-02 15:04:05 -0700"), true
+	case 'I':
+		return sig.When.Format("2006-01-02T15:04:05Z07:00"), true
+	case 't':
+		return strconv.FormatInt(sig.When.Unix(), 10), true
+	}
+
+	return "", false
+}
@@ -0,0 +1,162 @@
+// SPDX-FileCopyrightText: 2021 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+package git
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateArchiveAttributes(t *testing.T) {
+	files := map[string]testFile{
+		".gitattributes": {mode: filemode.Regular, data: ".github export-ignore\n" +
+			"/tests/fixtures export-ignore\n" +
+			"*.o export-ignore\n" +
+			"version.h export-subst\n"},
+		".github/workflows/ci.yml": {mode: filemode.Regular, data: "on: push\n"},
+		"tests/fixtures/big.bin":   {mode: filemode.Regular, data: "big\n"},
+		"tests/test.c":             {mode: filemode.Regular, data: "int test;\n"},
+		"lib/.gitattributes":       {mode: filemode.Regular, data: "secret.c export-ignore\n*.o -export-ignore\n"},
+		"lib/lib.o":                {mode: filemode.Regular, data: "o\n"},
+		"lib/secret.c":             {mode: filemode.Regular, data: "int secret;\n"},
+		"lib/version.h":            {mode: filemode.Regular, data: "#define V \"$Format:%h$\"\n"},
+		"main.o":                   {mode: filemode.Regular, data: "o\n"},
+		"version.h":                {mode: filemode.Regular, data: "#define V \"$Format:%H$\"\n"},
+	}
+
+	tests := []struct {
+		description         string
+		subdir              string
+		expected            []string
+		expectedExcluded    []string
+		expectedSubstituted []string
+	}{
+		{
+			description: "whole repo",
+			expected: []string{
+				"foo/", "foo/.gitattributes", "foo/lib/", "foo/lib/.gitattributes",
+				"foo/lib/lib.o", "foo/lib/version.h", "foo/tests/", "foo/tests/test.c",
+				"foo/version.h",
+			},
+			expectedExcluded:    []string{".github/", "lib/secret.c", "main.o", "tests/fixtures/"},
+			expectedSubstituted: []string{"lib/version.h", "version.h"},
+		}, {
+			description: "subdir uses the parent attributes",
+			subdir:      "lib",
+			expected: []string{
+				"foo/", "foo/.gitattributes", "foo/lib.o", "foo/version.h",
+			},
+			expectedExcluded:    []string{"secret.c"},
+			expectedSubstituted: []string{"version.h"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			assert := assert.New(t)
+
+			g := newTestRepo(t, "v1.0.0", files)
+			a, err := g.CreateArchive("foo", "v1.0.0", tc.subdir, Format{Name: FormatTarGz}, t.TempDir(), nil)
+			require.NoError(t, err)
+
+			hash, err := g.ResolveCommit("v1.0.0")
+			require.NoError(t, err)
+
+			entries, _ := readTar(t, a.File, FormatTarGz)
+			var names []string
+			for name := range entries {
+				names = append(names, name)
+			}
+			assert.ElementsMatch(tc.expected, names)
+			assert.ElementsMatch(tc.expectedExcluded, a.Excluded)
+			assert.ElementsMatch(tc.expectedSubstituted, a.Substituted)
+
+			if tc.subdir == "" {
+				assert.Equal("#define V \""+hash+"\"\n", entries["foo/version.h"].data)
+				assert.Equal("#define V \""+hash[:7]+"\"\n", entries["foo/lib/version.h"].data)
+			} else {
+				assert.Equal("#define V \""+hash[:7]+"\"\n", entries["foo/version.h"].data)
+			}
+		})
+	}
+}
+
+func TestCreateArchiveAttributesInvalid(t *testing.T) {
+	g := newTestRepo(t, "v1.0.0", map[string]testFile{
+		"lib/.gitattributes": {mode: filemode.Regular, data: "[attr]binary -diff -merge -text\n"},
+		"lib/lib.c":          {mode: filemode.Regular, data: "int x;\n"},
+	})
+
+	_, err := g.CreateArchive("foo", "v1.0.0", "", Format{Name: FormatZip}, t.TempDir(), nil)
+	assert.Error(t, err)
+}
+
+func TestSubstitute(t *testing.T) {
+	author := object.Signature{
+		Name:  "Author",
+		Email: "author@example.com",
+		When:  time.Date(2021, 6, 1, 12, 0, 0, 0, time.FixedZone("", 2*60*60)),
+	}
+	commit := &object.Commit{
+		Hash:      plumbing.NewHash("897a0f35d2dc5bcf0ef6e94b44e8e919b64d4a4d"),
+		TreeHash:  plumbing.NewHash("2e16f2ba3c77a94315de13f43d7cc1fb7312d573"),
+		Author:    author,
+		Committer: testSig,
+		Message:   "subject line\nwrapped\n\nbody\n",
+		ParentHashes: []plumbing.Hash{
+			plumbing.NewHash("0123456789012345678901234567890123456789"),
+			plumbing.NewHash("abcdefabcdefabcdefabcdefabcdefabcdefabcd"),
+		},
+	}
+
+	tests := []struct {
+		in       string
+		expected string
+	}{
+		{in: "no placeholders", expected: "no placeholders"},
+		{in: "$Format:%H$", expected: "897a0f35d2dc5bcf0ef6e94b44e8e919b64d4a4d"},
+		{in: "$Format:%h %T %t$", expected: "897a0f3 2e16f2ba3c77a94315de13f43d7cc1fb7312d573 2e16f2b"},
+		{in: "$Format:%P$", expected: "0123456789012345678901234567890123456789 abcdefabcdefabcdefabcdefabcdefabcdefabcd"},
+		{in: "$Format:%p$", expected: "0123456 abcdefa"},
+		{in: "$Format:%s$", expected: "subject line wrapped"},
+		{in: "$Format:%b$", expected: "body\n"},
+		{in: "$Format:%an <%ae>$", expected: "Author <author@example.com>"},
+		{in: "$Format:%ad$", expected: "Tue Jun 1 12:00:00 2021 +0200"},
+		{in: "$Format:%aD$", expected: "Tue, 1 Jun 2021 12:00:00 +0200"},
+		{in: "$Format:%ai$", expected: "2021-06-01 12:00:00 +0200"},
+		{in: "$Format:%aI$", expected: "2021-06-01T12:00:00+02:00"},
+		{in: "$Format:%at$", expected: "1622541600"},
+		{in: "$Format:%cn %ct$", expected: "Test 1622548800"},
+		{in: "$Format:%%%x%q%$", expected: "%%x%q%"},
+		{in: "$Format:a%nb$", expected: "a\nb"},
+		{in: "v$Format:%h$ and v$Format:%h$.", expected: "v897a0f3 and v897a0f3."},
+		{in: "$Format:%H", expected: "$Format:%H"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.in, func(t *testing.T) {
+			assert.Equal(t, tc.expected, string(substitute([]byte(tc.in), commit)))
+		})
+	}
+}
+
+func TestCreateArchiveSubstituteSymlink(t *testing.T) {
+	g := newTestRepo(t, "v1.0.0", map[string]testFile{
+		".gitattributes": {mode: filemode.Regular, data: "* export-subst\n"},
+		"link":           {mode: filemode.Symlink, data: "$Format:%H$"},
+	})
+
+	a, err := g.CreateArchive("foo", "v1.0.0", "", Format{Name: FormatTar}, t.TempDir(), nil)
+	require.NoError(t, err)
+
+	entries, _ := readTar(t, a.File, FormatTar)
+	assert.Equal(t, archived{mode: os.ModeSymlink | 0777, data: "$Format:%H$"}, entries["foo/link"])
+	assert.Equal(t, []string{".gitattributes"}, a.Substituted)
+}
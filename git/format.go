@@ -0,0 +1,161 @@
+// SPDX-FileCopyrightText: 2021 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+package git
+
+import (
+	"compress/flate"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/dsnet/compress/bzip2"
+	"github.com/klauspost/compress/zstd"
+	"github.com/ulikunitz/xz"
+)
+
+const (
+	FormatTar    = "tar"
+	FormatTarGz  = "tar.gz"
+	FormatTarXz  = "tar.xz"
+	FormatTarZst = "tar.zst"
+	FormatTarBz2 = "tar.bz2"
+	FormatZip    = "zip"
+)
+
+// gzipOSUnix is the operating system byte of the gzip header, fixed so the
+// archive is the same on every runner.
+const gzipOSUnix = 3
+
+var (
+	ErrArchiveFormat = errors.New("the archive format must be tar, tar.gz, tar.xz, tar.zst, tar.bz2 or zip")
+	ErrArchiveLevel  = errors.New("the compression level is out of range for the archive format")
+)
+
+// formatLevels is the range of compression levels each format accepts.
+var formatLevels = map[string][2]int{
+	FormatTar:    {0, 0},
+	FormatTarGz:  {1, 9},
+	FormatTarXz:  {1, 9},
+	FormatTarZst: {1, 22},
+	FormatTarBz2: {1, 9},
+	FormatZip:    {1, 9},
+}
+
+// xzDictCaps are the dictionary sizes of the xz presets 1 to 9.
+var xzDictCaps = [...]int{
+	1 << 20, 2 << 20, 4 << 20, 4 << 20, 8 << 20, 8 << 20, 16 << 20, 32 << 20, 64 << 20,
+}
+
+// Format is an archive format and the compression level used for it.  A level
+// of 0 uses the usual default of the format: 6 for gzip, zip and xz, 3 for
+// zstd and 9 for bzip2.
+type Format struct {
+	Name  string
+	Level int
+}
+
+// DefaultFormats are the archives created when none are specified.
+var DefaultFormats = []Format{
+	{Name: FormatZip},
+	{Name: FormatTarGz},
+}
+
+// ParseFormat parses a format optionally followed by a colon and the
+// compression level, like 'tar.xz:9'.
+func ParseFormat(s string) (Format, error) {
+	name, level, found := strings.Cut(strings.TrimSpace(s), ":")
+	f := Format{Name: strings.ToLower(name)}
+
+	if found {
+		var err error
+		if f.Level, err = strconv.Atoi(level); err != nil {
+			return Format{}, fmt.Errorf("%w: '%s'", ErrArchiveLevel, s)
+		}
+	}
+
+	if err := f.validate(); err != nil {
+		return Format{}, err
+	}
+
+	return f, nil
+}
+
+func (f Format) String() string {
+	if f.Level == 0 {
+		return f.Name
+	}
+	return f.Name + ":" + strconv.Itoa(f.Level)
+}
+
+// IsTar returns true if the format is a tar archive.
+func (f Format) IsTar() bool {
+	return f.Name != FormatZip
+}
+
+func (f Format) validate() error {
+	levels, found := formatLevels[f.Name]
+	if !found {
+		return fmt.Errorf("%w: '%s'", ErrArchiveFormat, f.Name)
+	}
+
+	if f.Level != 0 && (f.Level < levels[0] || f.Level > levels[1]) {
+		return fmt.Errorf("%w: '%s' accepts %d to %d", ErrArchiveLevel, f, levels[0], levels[1])
+	}
+
+	return nil
+}
+
+// level returns the compression level, or the default if none is set.
+func (f Format) level(def int) int {
+	if f.Level == 0 {
+		return def
+	}
+	return f.Level
+}
+
+// compressor returns the writer that compresses the tar archive.  Each
+// compressor produces the same output for the same input.
+func (f Format) compressor(w io.Writer) (io.WriteCloser, error) {
+	switch f.Name {
+	case FormatTar:
+		return nopWriteCloser{w}, nil
+	case FormatTarGz:
+		// The gzip header has no name or time and a fixed OS byte.
+		gz, err := gzip.NewWriterLevel(w, f.level(6))
+		if err != nil {
+			return nil, err
+		}
+		gz.OS = gzipOSUnix
+		return gz, nil
+	case FormatTarXz:
+		cfg := xz.WriterConfig{
+			DictCap: xzDictCaps[f.level(6)-1],
+		}
+		return cfg.NewWriter(w)
+	case FormatTarZst:
+		return zstd.NewWriter(w,
+			zstd.WithEncoderLevel(zstd.EncoderLevelFromZstd(f.level(3))),
+			zstd.WithEncoderConcurrency(1),
+		)
+	case FormatTarBz2:
+		return bzip2.NewWriter(w, &bzip2.WriterConfig{Level: f.level(9)})
+	}
+
+	return nil, fmt.Errorf("%w: '%s'", ErrArchiveFormat, f.Name)
+}
+
+// deflate returns the deflate compressor for zip archives.
+func (f Format) deflate(w io.Writer) (io.WriteCloser, error) {
+	return flate.NewWriter(w, f.level(6))
+}
+
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error {
+	return nil
+}
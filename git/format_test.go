@@ -0,0 +1,52 @@
+// SPDX-FileCopyrightText: 2021 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+package git
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseFormat(t *testing.T) {
+	tests := []struct {
+		in          string
+		expected    Format
+		expectedErr error
+	}{
+		{in: "zip", expected: Format{Name: FormatZip}},
+		{in: " TAR.GZ ", expected: Format{Name: FormatTarGz}},
+		{in: "tar.xz:9", expected: Format{Name: FormatTarXz, Level: 9}},
+		{in: "tar.zst:22", expected: Format{Name: FormatTarZst, Level: 22}},
+		{in: "tar.bz2:1", expected: Format{Name: FormatTarBz2, Level: 1}},
+		{in: "tar", expected: Format{Name: FormatTar}},
+		{in: "tar:1", expectedErr: ErrArchiveLevel},
+		{in: "tar.gz:10", expectedErr: ErrArchiveLevel},
+		{in: "tar.zst:23", expectedErr: ErrArchiveLevel},
+		{in: "zip:-1", expectedErr: ErrArchiveLevel},
+		{in: "zip:best", expectedErr: ErrArchiveLevel},
+		{in: "rar", expectedErr: ErrArchiveFormat},
+		{in: "", expectedErr: ErrArchiveFormat},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.in, func(t *testing.T) {
+			assert := assert.New(t)
+
+			got, err := ParseFormat(tc.in)
+			if tc.expectedErr != nil {
+				assert.ErrorIs(err, tc.expectedErr)
+				return
+			}
+			assert.NoError(err)
+			assert.Equal(tc.expected, got)
+		})
+	}
+}
+
+func TestFormatString(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal("tar.gz", Format{Name: FormatTarGz}.String())
+	assert.Equal("tar.xz:9", Format{Name: FormatTarXz, Level: 9}.String())
+}
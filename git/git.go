@@ -3,23 +3,103 @@
 package git
 
 import (
+	"errors"
 	"fmt"
 	"os"
-	"os/exec"
+	"path"
+	"strings"
+	"time"
 
 	"github.com/go-git/go-git/v5"
 	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/storer"
+	"github.com/go-git/go-git/v5/plumbing/transport"
 	"github.com/go-git/go-git/v5/plumbing/transport/http"
 )
 
+var (
+	ErrVersionNotFound   = errors.New("version not found in the changelog history")
+	ErrRemoteTagMismatch = errors.New("the remote tags do not match the local tags")
+	ErrTagRace           = errors.New("the tag was pushed by another run")
+	ErrPushIncomplete    = errors.New("the push failed after tags reached the upstream repo")
+	ErrTagNotFound       = errors.New("the tag is not present in the repo")
+	ErrCommitMissing     = errors.New("the commit is not present in the repo")
+	ErrFileNotFound      = errors.New("the file is not present in the commit")
+)
+
+// TagRef is a tag in the upstream/remote repo.
+type TagRef struct {
+	// Object is the hash the tag refers to, which is the tag object for
+	// annotated tags.
+	Object string
+
+	// Commit is the hash of the commit the tag points at.
+	Commit string
+}
+
+// TagInfo describes a tag in the local repo.
+type TagInfo struct {
+	// Commit is the hash of the commit the tag points at.
+	Commit string
+
+	// Annotated is true for tags with a tag object.
+	Annotated bool
+
+	// Tagger is the name and email of who created an annotated tag.
+	Tagger string
+
+	// Date is when an annotated tag was created.
+	Date time.Time
+}
+
+// Options control how tags are created and verified and how archives are
+// built.
+type Options struct {
+	// TaggerName and TaggerEmail are the identity used for new tags.  The
+	// committer of the tagged commit is used for any that are empty.
+	TaggerName  string
+	TaggerEmail string
+
+	// SigningFormat is either SigningOpenPGP or SigningSSH.  It is detected
+	// from the SigningKey if empty.
+	SigningFormat string
+
+	// SigningKey is the private key used to sign new tags.  Tags are not
+	// signed if it is empty.
+	SigningKey string
+
+	// SigningPassphrase decrypts the SigningKey if it is encrypted.
+	SigningPassphrase string
+
+	// AllowedSigners are the keys that may sign tags.  Armored OpenPGP public
+	// key blocks and SSH public keys in the authorized_keys or git
+	// allowed_signers formats, one per line, may be mixed.
+	AllowedSigners string
+
+	// Submodules includes the contents of the submodules in the archives.
+	Submodules bool
+
+	// LFS replaces the Git LFS pointer files in the archives with the
+	// objects they refer to.
+	LFS bool
+}
+
 // Git encapsulates the difficult to test go-git code.
 type Git struct {
-	repo *git.Repository
+	repo    *git.Repository
+	opts    Options
+	signer  signer
+	allowed *allowedSigners
 }
 
-// Open opens a path as a git repository or errors out.
-func Open(path string) (*Git, error) {
-	g := &Git{}
+// Open opens a path as a git repository or errors out.  The signing keys in
+// the options are loaded so problems are found before anything is changed.
+func Open(path string, opts Options) (*Git, error) {
+	g := &Git{
+		opts: opts,
+	}
 
 	repo, err := git.PlainOpen(path)
 	if err != nil {
@@ -27,6 +107,16 @@ func Open(path string) (*Git, error) {
 	}
 	g.repo = repo
 
+	if opts.SigningKey != "" {
+		if g.signer, err = newSigner(opts.SigningFormat, opts.SigningKey, opts.SigningPassphrase); err != nil {
+			return nil, err
+		}
+	}
+
+	if g.allowed, err = parseAllowedSigners(opts.AllowedSigners); err != nil {
+		return nil, err
+	}
+
 	return g, nil
 }
 
@@ -44,67 +134,712 @@ func (g *Git) IsTagPresent(tag string) (bool, error) {
 	return false, fmt.Errorf("%w: unable to process git repo", err)
 }
 
+// Tags returns the names of all the tags present in the repo.
+func (g *Git) Tags() ([]string, error) {
+	iter, err := g.repo.Tags()
+	if err != nil {
+		return nil, fmt.Errorf("%w: repo.Tags() error", err)
+	}
+	defer iter.Close()
+
+	var tags []string
+	err = iter.ForEach(func(ref *plumbing.Reference) error {
+		tags = append(tags, ref.Name().Short())
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w: unable to list the tags", err)
+	}
+
+	return tags, nil
+}
+
 // TagHead adds the specified tag to the head of the repo.
 func (g *Git) TagHead(tag, msg string) error {
 	head, err := g.repo.Head()
 	if err != nil {
 		return fmt.Errorf("%w: repo.Head() error", err)
 	}
-	hash := head.Hash()
-	commit, err := g.repo.CommitObject(hash)
+
+	return g.TagCommit(tag, msg, head.Hash().String())
+}
+
+// TagCommit adds the specified tag to the commit with the specified hash.
+// The tag is signed if a signing key is configured.
+func (g *Git) TagCommit(tag, msg, hash string) error {
+	h := plumbing.NewHash(hash)
+	commit, err := g.repo.CommitObject(h)
 	if err != nil {
 		return fmt.Errorf("%w: repo.CommitObject() error", err)
 	}
-	_, err = g.repo.CreateTag(tag, hash, &git.CreateTagOptions{
-		Tagger:  &commit.Committer,
-		Message: msg,
+
+	tagger := g.identity(commit.Committer)
+
+	if g.signer == nil {
+		_, err = g.repo.CreateTag(tag, h, &git.CreateTagOptions{
+			Tagger:  &tagger,
+			Message: msg,
+		})
+		if err != nil {
+			return fmt.Errorf("%w: repo.CreateTag() error for tag '%s'", err, tag)
+		}
+		return nil
+	}
+
+	// go-git only signs tags with OpenPGP keys, so the tag object is built
+	// here to support SSH keys as well.
+	name := plumbing.NewTagReferenceName(tag)
+	if _, err = g.repo.Reference(name, false); err == nil {
+		return fmt.Errorf("%w: '%s'", git.ErrTagExists, tag)
+	}
+
+	obj := &object.Tag{
+		Name:       tag,
+		Tagger:     tagger,
+		Message:    strings.TrimSpace(msg) + "\n",
+		TargetType: plumbing.CommitObject,
+		Target:     h,
+	}
+	payload, err := encodeTag(obj)
+	if err != nil {
+		return fmt.Errorf("%w: unable to encode the tag '%s'", err, tag)
+	}
+	if obj.PGPSignature, err = g.signer.sign(payload); err != nil {
+		return fmt.Errorf("%w: unable to sign the tag '%s'", err, tag)
+	}
+
+	encoded := g.repo.Storer.NewEncodedObject()
+	if err = obj.Encode(encoded); err != nil {
+		return fmt.Errorf("%w: unable to encode the tag '%s'", err, tag)
+	}
+	target, err := g.repo.Storer.SetEncodedObject(encoded)
+	if err != nil {
+		return fmt.Errorf("%w: unable to store the tag '%s'", err, tag)
+	}
+
+	if err = g.repo.Storer.SetReference(plumbing.NewHashReference(name, target)); err != nil {
+		return fmt.Errorf("%w: unable to create the tag '%s'", err, tag)
+	}
+
+	return nil
+}
+
+// MoveTag points the tag at the commit with the specified hash, replacing
+// the tag if it is present.  The hash of the object the tag referred to
+// before is returned, or an empty string if the tag wasn't present.
+func (g *Git) MoveTag(tag, msg, hash string) (string, error) {
+	var prev string
+
+	ref, err := g.repo.Tag(tag)
+	switch {
+	case err == nil:
+		prev = ref.Hash().String()
+		if err = g.repo.Storer.RemoveReference(ref.Name()); err != nil {
+			return "", fmt.Errorf("%w: unable to remove the tag '%s'", err, tag)
+		}
+	case !errors.Is(err, git.ErrTagNotFound):
+		return "", fmt.Errorf("%w: unable to find the tag '%s'", err, tag)
+	}
+
+	if err = g.TagCommit(tag, msg, hash); err != nil {
+		if prev != "" {
+			_ = g.RestoreTag(tag, prev)
+		}
+		return "", err
+	}
+
+	return prev, nil
+}
+
+// RestoreTag points the tag back at the object it referred to before it was
+// moved, or removes the tag if the object is empty.
+func (g *Git) RestoreTag(tag, object string) error {
+	name := plumbing.NewTagReferenceName(tag)
+	if object == "" {
+		if err := g.repo.Storer.RemoveReference(name); err != nil {
+			return fmt.Errorf("%w: unable to remove the tag '%s'", err, tag)
+		}
+		return nil
+	}
+
+	ref := plumbing.NewHashReference(name, plumbing.NewHash(object))
+	if err := g.repo.Storer.SetReference(ref); err != nil {
+		return fmt.Errorf("%w: unable to restore the tag '%s'", err, tag)
+	}
+	return nil
+}
+
+// DeleteTag removes the specified tag from the repo.
+func (g *Git) DeleteTag(tag string) error {
+	if err := g.repo.DeleteTag(tag); err != nil {
+		return fmt.Errorf("%w: repo.DeleteTag() error for tag '%s'", err, tag)
+	}
+	return nil
+}
+
+// VerifyTag checks the tag is an annotated tag signed by one of the allowed
+// signing keys and returns a description of the key used.
+func (g *Git) VerifyTag(tag string) (string, error) {
+	if g.allowed.empty() {
+		return "", fmt.Errorf("%w: no allowed signing keys are configured", ErrTagSignature)
+	}
+
+	ref, err := g.repo.Tag(tag)
+	if err != nil {
+		if errors.Is(err, git.ErrTagNotFound) {
+			return "", fmt.Errorf("%w: '%s'", ErrTagNotFound, tag)
+		}
+		return "", fmt.Errorf("%w: unable to find the tag '%s'", err, tag)
+	}
+
+	obj, err := g.repo.TagObject(ref.Hash())
+	if err != nil {
+		if errors.Is(err, plumbing.ErrObjectNotFound) {
+			return "", fmt.Errorf("%w: '%s' is a lightweight tag", ErrTagUnsigned, tag)
+		}
+		return "", fmt.Errorf("%w: repo.TagObject() error for tag '%s'", err, tag)
+	}
+
+	if obj.PGPSignature == "" {
+		return "", fmt.Errorf("%w: '%s'", ErrTagUnsigned, tag)
+	}
+
+	payload, err := encodeTag(obj)
+	if err != nil {
+		return "", fmt.Errorf("%w: unable to encode the tag '%s'", err, tag)
+	}
+
+	key, err := g.allowed.verify(payload, obj.PGPSignature)
+	if err != nil {
+		return "", fmt.Errorf("%w: '%s'", err, tag)
+	}
+
+	return key, nil
+}
+
+// FindVersionCommit walks the history of the changelog file backwards from the
+// head of the repo and returns the hash of the commit that first added the
+// heading for the specified version.
+func (g *Git) FindVersionCommit(file, version string) (string, error) {
+	head, err := g.repo.Head()
+	if err != nil {
+		return "", fmt.Errorf("%w: repo.Head() error", err)
+	}
+
+	iter, err := g.repo.Log(&git.LogOptions{
+		From:     head.Hash(),
+		FileName: &file,
+	})
+	if err != nil {
+		return "", fmt.Errorf("%w: repo.Log() error for file '%s'", err, file)
+	}
+	defer iter.Close()
+
+	var found plumbing.Hash
+	err = iter.ForEach(func(c *object.Commit) error {
+		f, err := c.File(file)
+		if err != nil {
+			if errors.Is(err, object.ErrFileNotFound) {
+				return storer.ErrStop
+			}
+			return err
+		}
+
+		contents, err := f.Contents()
+		if err != nil {
+			return err
+		}
+
+		if !hasVersionHeading(contents, version) {
+			return storer.ErrStop
+		}
+
+		found = c.Hash
+		return nil
+	})
+	if err != nil {
+		return "", fmt.Errorf("%w: unable to walk the history of '%s'", err, file)
+	}
+
+	if found.IsZero() {
+		return "", fmt.Errorf("%w: '%s' in '%s'", ErrVersionNotFound, version, file)
+	}
+
+	return found.String(), nil
+}
+
+// ResolveCommit returns the hash of the commit a revision like a branch, tag
+// or hash refers to.
+func (g *Git) ResolveCommit(rev string) (string, error) {
+	hash, err := g.repo.ResolveRevision(plumbing.Revision(rev))
+	if err != nil {
+		return "", fmt.Errorf("%w: repo.ResolveRevision() error for '%s'", err, rev)
+	}
+
+	if _, err = g.repo.CommitObject(*hash); err != nil {
+		return "", fmt.Errorf("%w: '%s' is not a commit", err, rev)
+	}
+
+	return hash.String(), nil
+}
+
+// InspectTag returns the commit the tag points at and, for annotated tags,
+// who created it and when.
+func (g *Git) InspectTag(tag string) (TagInfo, error) {
+	var info TagInfo
+
+	ref, err := g.repo.Tag(tag)
+	if err != nil {
+		if errors.Is(err, git.ErrTagNotFound) {
+			return info, fmt.Errorf("%w: '%s'", ErrTagNotFound, tag)
+		}
+		return info, fmt.Errorf("%w: unable to find the tag '%s'", err, tag)
+	}
+
+	obj, err := g.repo.TagObject(ref.Hash())
+	if err != nil {
+		if errors.Is(err, plumbing.ErrObjectNotFound) {
+			// Lightweight tags refer to the commit directly.
+			info.Commit = ref.Hash().String()
+			return info, nil
+		}
+		return info, fmt.Errorf("%w: repo.TagObject() error for tag '%s'", err, tag)
+	}
+
+	commit, err := obj.Commit()
+	if err != nil {
+		return info, fmt.Errorf("%w: '%s' doesn't point at a commit", err, tag)
+	}
+
+	info.Commit = commit.Hash.String()
+	info.Annotated = true
+	info.Tagger = fmt.Sprintf("%s <%s>", obj.Tagger.Name, obj.Tagger.Email)
+	info.Date = obj.Tagger.When
+
+	return info, nil
+}
+
+// HasVersion returns true if the changelog file at the commit has a release
+// heading for the specified version.
+func (g *Git) HasVersion(hash, file, version string) (bool, error) {
+	contents, err := g.ReadFile(hash, file)
+	if err != nil {
+		if errors.Is(err, ErrFileNotFound) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	return hasVersionHeading(string(contents), version), nil
+}
+
+// ReadFile returns the contents of the file at the commit with the specified
+// hash.
+func (g *Git) ReadFile(hash, file string) ([]byte, error) {
+	commit, err := g.repo.CommitObject(plumbing.NewHash(hash))
+	if err != nil {
+		if errors.Is(err, plumbing.ErrObjectNotFound) {
+			return nil, fmt.Errorf("%w: '%s'", ErrCommitMissing, hash)
+		}
+		return nil, fmt.Errorf("%w: repo.CommitObject() error for '%s'", err, hash)
+	}
+
+	f, err := commit.File(file)
+	if err != nil {
+		if errors.Is(err, object.ErrFileNotFound) {
+			return nil, fmt.Errorf("%w: '%s' at %s", ErrFileNotFound, file, hash)
+		}
+		return nil, fmt.Errorf("%w: unable to read '%s' at %s", err, file, hash)
+	}
+
+	contents, err := f.Contents()
+	if err != nil {
+		return nil, fmt.Errorf("%w: unable to read '%s' at %s", err, file, hash)
+	}
+
+	return []byte(contents), nil
+}
+
+// ListFiles returns the paths of the files below the directory at the commit
+// with the specified hash.  An empty directory lists every file.
+func (g *Git) ListFiles(hash, dir string) ([]string, error) {
+	commit, err := g.repo.CommitObject(plumbing.NewHash(hash))
+	if err != nil {
+		if errors.Is(err, plumbing.ErrObjectNotFound) {
+			return nil, fmt.Errorf("%w: '%s'", ErrCommitMissing, hash)
+		}
+		return nil, fmt.Errorf("%w: repo.CommitObject() error for '%s'", err, hash)
+	}
+
+	tree, err := commit.Tree()
+	if err != nil {
+		return nil, fmt.Errorf("%w: unable to read the tree of %s", err, hash)
+	}
+	if dir != "" {
+		if tree, err = tree.Tree(dir); err != nil {
+			if errors.Is(err, object.ErrDirectoryNotFound) {
+				return nil, nil
+			}
+			return nil, fmt.Errorf("%w: unable to read '%s' at %s", err, dir, hash)
+		}
+	}
+
+	var files []string
+	err = tree.Files().ForEach(func(f *object.File) error {
+		files = append(files, path.Join(dir, f.Name))
+		return nil
 	})
 	if err != nil {
-		return fmt.Errorf("%w: repo.CreateTag() error for tag '%s'", err, tag)
+		return nil, fmt.Errorf("%w: unable to list '%s' at %s", err, dir, hash)
+	}
+
+	return files, nil
+}
+
+// hasVersionHeading returns true if the changelog contents have a release
+// heading for the specified version.
+func hasVersionHeading(contents, version string) bool {
+	for _, line := range strings.Split(contents, "\n") {
+		line = strings.TrimSpace(line)
+		if !strings.HasPrefix(line, "## ") {
+			continue
+		}
+		line = strings.TrimSpace(strings.TrimPrefix(line, "## "))
+		if strings.HasPrefix(line, "["+version+"]") {
+			return true
+		}
+		if line == version || strings.HasPrefix(line, version+" ") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// CommitToBranch creates a new branch at the head of the repo, checks it out
+// keeping the working tree changes and commits the specified files to it.
+// The hash of the new commit is returned.
+func (g *Git) CommitToBranch(branch, msg string, files ...string) (string, error) {
+	head, err := g.repo.Head()
+	if err != nil {
+		return "", fmt.Errorf("%w: repo.Head() error", err)
+	}
+	commit, err := g.repo.CommitObject(head.Hash())
+	if err != nil {
+		return "", fmt.Errorf("%w: repo.CommitObject() error", err)
+	}
+
+	wt, err := g.repo.Worktree()
+	if err != nil {
+		return "", fmt.Errorf("%w: repo.Worktree() error", err)
+	}
+
+	err = wt.Checkout(&git.CheckoutOptions{
+		Branch: plumbing.NewBranchReferenceName(branch),
+		Create: true,
+		Keep:   true,
+	})
+	if err != nil {
+		return "", fmt.Errorf("%w: unable to create the branch '%s'", err, branch)
+	}
+
+	for _, file := range files {
+		if _, err = wt.Add(file); err != nil {
+			return "", fmt.Errorf("%w: unable to add the file '%s'", err, file)
+		}
+	}
+
+	sig := g.identity(commit.Committer)
+	sig.When = time.Now()
+	hash, err := wt.Commit(msg, &git.CommitOptions{
+		Author:    &sig,
+		Committer: &sig,
+	})
+	if err != nil {
+		return "", fmt.Errorf("%w: unable to commit to the branch '%s'", err, branch)
+	}
+
+	return hash.String(), nil
+}
+
+// identity returns the configured tagger identity used for new tags and
+// commits.  Any part that isn't configured comes from the fallback, which is
+// used as is, time included, when neither part is configured.
+func (g *Git) identity(fallback object.Signature) object.Signature {
+	sig := fallback
+	if g.opts.TaggerName != "" || g.opts.TaggerEmail != "" {
+		sig.When = time.Now()
+		if g.opts.TaggerName != "" {
+			sig.Name = g.opts.TaggerName
+		}
+		if g.opts.TaggerEmail != "" {
+			sig.Email = g.opts.TaggerEmail
+		}
+	}
+
+	return sig
+}
+
+// PushBranch pushes the branch to the upstream/remote repo.
+func (g *Git) PushBranch(token, branch string) error {
+	ref := plumbing.NewBranchReferenceName(branch)
+	return g.push(token, config.RefSpec(ref+":"+ref))
+}
+
+// PushTags pushes the specified tags to the upstream/remote repo and then
+// confirms each remote tag refers to the same object as the local tag.  Once
+// any of the tags may be upstream a failure is reported as ErrPushIncomplete
+// since the release is already published.
+func (g *Git) PushTags(token string, tags ...string) error {
+	if len(tags) == 0 {
+		return nil
+	}
+
+	if err := g.checkTagRace(token, tags); err != nil {
+		return err
+	}
+
+	refspecs := make([]config.RefSpec, 0, len(tags))
+	for _, tag := range tags {
+		ref := plumbing.NewTagReferenceName(tag)
+		refspecs = append(refspecs, config.RefSpec(ref+":"+ref))
+	}
+
+	if err := g.push(token, refspecs...); err != nil {
+		pushed, rerr := g.pushedTags(token, tags)
+		switch {
+		case rerr != nil:
+			return fmt.Errorf("%w: unable to check the upstream tags after %s", ErrPushIncomplete, err)
+		case len(pushed) > 0:
+			return fmt.Errorf("%w: %s were pushed: %s", ErrPushIncomplete, strings.Join(pushed, ", "), err)
+		}
+		return err
+	}
+
+	if err := g.verifyRemoteTags(token, tags); err != nil {
+		return fmt.Errorf("%w: %s", ErrPushIncomplete, err)
 	}
 
 	return nil
 }
 
-// PushTags pushes the tags to the upstream/remote repo.
-func (g *Git) PushTags(token string) error {
-	opts := &git.PushOptions{
-		RemoteName: "origin",
-		Progress:   os.Stdout,
-		RefSpecs:   []config.RefSpec{config.RefSpec("refs/tags/*:refs/tags/*")},
-		Auth: &http.BasicAuth{
-			Username: "ignored",
-			Password: token,
-		},
+// pushedTags returns the tags whose remote tag refers to the same object as
+// the local tag.
+func (g *Git) pushedTags(token string, tags []string) ([]string, error) {
+	remote, err := g.RemoteTags(token)
+	if err != nil {
+		return nil, err
 	}
 
-	if err := opts.Validate(); err != nil {
-		return fmt.Errorf("%w: failed opts.PushOptions.Validate()", err)
+	var pushed []string
+	for _, tag := range tags {
+		object, _, err := g.localTag(tag)
+		if err != nil {
+			return nil, err
+		}
+		if r, found := remote[tag]; found && r.Object == object {
+			pushed = append(pushed, tag)
+		}
 	}
 
-	if err := g.repo.Push(opts); err != nil {
-		return fmt.Errorf("%w: failed repo.Push()", err)
+	return pushed, nil
+}
+
+// PushAliasTags force pushes the specified tags, which move from release to
+// release, to the upstream/remote repo and then confirms each remote tag
+// refers to the same object as the local tag.
+func (g *Git) PushAliasTags(token string, tags ...string) error {
+	if len(tags) == 0 {
+		return nil
+	}
+
+	refspecs := make([]config.RefSpec, 0, len(tags))
+	for _, tag := range tags {
+		ref := plumbing.NewTagReferenceName(tag)
+		refspecs = append(refspecs, config.RefSpec("+"+ref+":"+ref))
+	}
+
+	if err := g.push(token, refspecs...); err != nil {
+		return err
+	}
+
+	return g.verifyRemoteTags(token, tags)
+}
+
+// checkTagRace ensures none of the tags were pushed by another run since the
+// tags were examined.  Nothing is pushed if one was.
+func (g *Git) checkTagRace(token string, tags []string) error {
+	remote, err := g.RemoteTags(token)
+	if err != nil {
+		return err
+	}
+
+	var problems []string
+	for _, tag := range tags {
+		r, found := remote[tag]
+		if !found {
+			continue
+		}
+
+		object, commit, err := g.localTag(tag)
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case r.Object == object:
+			// Already pushed, possibly by an earlier attempt of this run.
+		case r.Commit != commit:
+			problems = append(problems, fmt.Sprintf("'%s' points at %s instead of %s",
+				tag, r.Commit, commit))
+		default:
+			problems = append(problems, fmt.Sprintf("'%s' is a different tag object for the same commit", tag))
+		}
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("%w: nothing was pushed: %s", ErrTagRace, strings.Join(problems, ", "))
 	}
 
 	return nil
 }
 
-// CreateArchive creates the archive file based on the naming conventions.
-func (g *Git) CreateArchive(slug, version, format, path string) (string, error) {
-	base := path + "/" + slug
+// localTag returns the hash of the object the local tag refers to and the
+// commit it points at.
+func (g *Git) localTag(tag string) (string, string, error) {
+	ref, err := g.repo.Tag(tag)
+	if err != nil {
+		return "", "", fmt.Errorf("%w: unable to find the tag '%s'", err, tag)
+	}
 
-	args := []string{
-		"archive",
-		"--format=" + format,
-		"-o", base + "." + format,
-		"--prefix=" + slug + "/",
-		version,
+	obj, err := g.repo.TagObject(ref.Hash())
+	if err != nil {
+		if errors.Is(err, plumbing.ErrObjectNotFound) {
+			// Lightweight tags refer to the commit directly.
+			return ref.Hash().String(), ref.Hash().String(), nil
+		}
+		return "", "", fmt.Errorf("%w: repo.TagObject() error for tag '%s'", err, tag)
 	}
 
-	out, err := exec.Command("git", args...).CombinedOutput()
+	return ref.Hash().String(), obj.Target.String(), nil
+}
+
+// verifyRemoteTags re-reads the remote refs and checks each tag refers to the
+// same object as the local tag.  Every difference is reported.
+func (g *Git) verifyRemoteTags(token string, tags []string) error {
+	remote, err := g.RemoteTags(token)
 	if err != nil {
-		return "", fmt.Errorf("%w: %s... unable to generate the %s archive", err, string(out), format)
+		return err
 	}
 
-	return base + "." + format, nil
+	var problems []string
+	for _, tag := range tags {
+		object, _, err := g.localTag(tag)
+		if err != nil {
+			return err
+		}
+
+		r, found := remote[tag]
+		switch {
+		case !found:
+			problems = append(problems, fmt.Sprintf("'%s' is missing", tag))
+		case r.Object != object:
+			problems = append(problems, fmt.Sprintf("'%s' is %s instead of %s",
+				tag, r.Object, object))
+		}
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("%w: %s", ErrRemoteTagMismatch, strings.Join(problems, ", "))
+	}
+
+	return nil
+}
+
+// RemoteTags returns the tags of the upstream/remote repo along with the
+// object and commit each refers to.
+func (g *Git) RemoteTags(token string) (map[string]TagRef, error) {
+	remote, err := g.repo.Remote("origin")
+	if err != nil {
+		return nil, fmt.Errorf("%w: repo.Remote() error", err)
+	}
+
+	refs, err := remote.List(&git.ListOptions{
+		Auth:          auth(token),
+		PeelingOption: git.AppendPeeled,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w: unable to list the remote refs", err)
+	}
+
+	// Annotated tags are listed twice, the second time with a '^{}' suffix
+	// and the commit the tag points at.
+	tags := map[string]TagRef{}
+	peeled := map[string]string{}
+	for _, ref := range refs {
+		name := ref.Name().String()
+		if !strings.HasPrefix(name, "refs/tags/") {
+			continue
+		}
+		tag := strings.TrimPrefix(name, "refs/tags/")
+		if strings.HasSuffix(tag, "^{}") {
+			peeled[strings.TrimSuffix(tag, "^{}")] = ref.Hash().String()
+			continue
+		}
+		tags[tag] = TagRef{
+			Object: ref.Hash().String(),
+			Commit: ref.Hash().String(),
+		}
+	}
+	for tag, commit := range peeled {
+		if r, found := tags[tag]; found {
+			r.Commit = commit
+			tags[tag] = r
+		}
+	}
+
+	return tags, nil
+}
+
+// IsShallow returns true if the repo is a shallow clone, which is missing
+// some of the history and often the tags.
+func (g *Git) IsShallow() (bool, error) {
+	shallow, err := g.repo.Storer.Shallow()
+	if err != nil {
+		return false, fmt.Errorf("%w: unable to read the shallow commits", err)
+	}
+
+	return len(shallow) > 0, nil
+}
+
+// auth returns the credentials for the upstream/remote repo.  Public repos
+// can be read without a token.
+func auth(token string) transport.AuthMethod {
+	if token == "" {
+		return nil
+	}
+	return &http.BasicAuth{
+		Username: "ignored",
+		Password: token,
+	}
+}
+
+func (g *Git) push(token string, refspecs ...config.RefSpec) error {
+	opts := &git.PushOptions{
+		RemoteName: "origin",
+		Progress:   os.Stdout,
+		RefSpecs:   refspecs,
+		Auth:       auth(token),
+	}
+
+	if err := opts.Validate(); err != nil {
+		return fmt.Errorf("%w: failed opts.PushOptions.Validate()", err)
+	}
+
+	err := g.repo.Push(opts)
+	if err != nil && !errors.Is(err, git.NoErrAlreadyUpToDate) {
+		return fmt.Errorf("%w: failed repo.Push()", err)
+	}
+
+	return nil
 }
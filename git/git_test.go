@@ -0,0 +1,516 @@
+// SPDX-FileCopyrightText: 2021 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+package git
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-billy/v5/memfs"
+	"github.com/go-git/go-billy/v5/util"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/storage/memory"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInspectTag(t *testing.T) {
+	repo, err := git.Init(memory.NewStorage(), nil)
+	require.NoError(t, err)
+	hash := storeCommit(t, repo, map[string]testFile{
+		"main.c": {mode: filemode.Regular, data: "int main;\n"},
+	})
+	_, err = repo.CreateTag("v1.0.0", hash, &git.CreateTagOptions{
+		Tagger:  &testSig,
+		Message: "Releasing: v1.0.0",
+	})
+	require.NoError(t, err)
+	_, err = repo.CreateTag("v1.0.1", hash, nil)
+	require.NoError(t, err)
+
+	tests := []struct {
+		description string
+		tag         string
+		expected    TagInfo
+		expectedErr error
+	}{
+		{
+			description: "annotated",
+			tag:         "v1.0.0",
+			expected: TagInfo{
+				Commit:    hash.String(),
+				Annotated: true,
+				Tagger:    "Test <test@example.com>",
+				Date:      testSig.When,
+			},
+		}, {
+			description: "lightweight",
+			tag:         "v1.0.1",
+			expected:    TagInfo{Commit: hash.String()},
+		}, {
+			description: "missing",
+			tag:         "v2.0.0",
+			expectedErr: ErrTagNotFound,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			assert := assert.New(t)
+
+			g := &Git{repo: repo}
+			got, err := g.InspectTag(tc.tag)
+			if tc.expectedErr != nil {
+				assert.True(errors.Is(err, tc.expectedErr),
+					fmt.Errorf("error [%v] doesn't contain error [%v] in its err chain",
+						err, tc.expectedErr),
+				)
+				return
+			}
+			assert.NoError(err)
+			assert.Equal(tc.expected.Commit, got.Commit)
+			assert.Equal(tc.expected.Annotated, got.Annotated)
+			assert.Equal(tc.expected.Tagger, got.Tagger)
+			assert.True(tc.expected.Date.Equal(got.Date))
+		})
+	}
+}
+
+func TestHasVersion(t *testing.T) {
+	repo, err := git.Init(memory.NewStorage(), nil)
+	require.NoError(t, err)
+	hash := storeCommit(t, repo, map[string]testFile{
+		"CHANGELOG.md": {mode: filemode.Regular, data: "# Changelog\n\n## [Unreleased]\n\n## [v1.0.0]\n- Initial.\n"},
+	})
+
+	tests := []struct {
+		description string
+		hash        string
+		file        string
+		version     string
+		expected    bool
+		expectedErr error
+	}{
+		{
+			description: "listed",
+			hash:        hash.String(),
+			file:        "CHANGELOG.md",
+			version:     "v1.0.0",
+			expected:    true,
+		}, {
+			description: "not listed",
+			hash:        hash.String(),
+			file:        "CHANGELOG.md",
+			version:     "v1.1.0",
+		}, {
+			description: "no changelog",
+			hash:        hash.String(),
+			file:        "docs/CHANGELOG.md",
+			version:     "v1.0.0",
+		}, {
+			description: "missing commit",
+			hash:        "0123456789012345678901234567890123456789",
+			file:        "CHANGELOG.md",
+			version:     "v1.0.0",
+			expectedErr: ErrCommitMissing,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			assert := assert.New(t)
+
+			g := &Git{repo: repo}
+			got, err := g.HasVersion(tc.hash, tc.file, tc.version)
+			if tc.expectedErr != nil {
+				assert.True(errors.Is(err, tc.expectedErr),
+					fmt.Errorf("error [%v] doesn't contain error [%v] in its err chain",
+						err, tc.expectedErr),
+				)
+				return
+			}
+			assert.NoError(err)
+			assert.Equal(tc.expected, got)
+		})
+	}
+}
+
+func TestReadFile(t *testing.T) {
+	repo, err := git.Init(memory.NewStorage(), nil)
+	require.NoError(t, err)
+	hash := storeCommit(t, repo, map[string]testFile{
+		"meson.build":     {mode: filemode.Regular, data: "project('foo')\n"},
+		"sub/meson.build": {mode: filemode.Regular, data: "project('sub')\n"},
+	})
+
+	tests := []struct {
+		description string
+		hash        string
+		file        string
+		expected    string
+		expectedErr error
+	}{
+		{
+			description: "top directory",
+			hash:        hash.String(),
+			file:        "meson.build",
+			expected:    "project('foo')\n",
+		}, {
+			description: "subdirectory",
+			hash:        hash.String(),
+			file:        "sub/meson.build",
+			expected:    "project('sub')\n",
+		}, {
+			description: "missing file",
+			hash:        hash.String(),
+			file:        "VERSION",
+			expectedErr: ErrFileNotFound,
+		}, {
+			description: "missing commit",
+			hash:        "0123456789012345678901234567890123456789",
+			file:        "meson.build",
+			expectedErr: ErrCommitMissing,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			assert := assert.New(t)
+
+			g := &Git{repo: repo}
+			got, err := g.ReadFile(tc.hash, tc.file)
+			if tc.expectedErr != nil {
+				assert.True(errors.Is(err, tc.expectedErr),
+					fmt.Errorf("error [%v] doesn't contain error [%v] in its err chain",
+						err, tc.expectedErr),
+				)
+				return
+			}
+			assert.NoError(err)
+			assert.Equal(tc.expected, string(got))
+		})
+	}
+}
+
+func TestListFiles(t *testing.T) {
+	repo, err := git.Init(memory.NewStorage(), nil)
+	require.NoError(t, err)
+	hash := storeCommit(t, repo, map[string]testFile{
+		"meson.build":        {mode: filemode.Regular, data: "project('foo')\n"},
+		"sub/meson.build":    {mode: filemode.Regular, data: "project('sub')\n"},
+		"sub/pkg/version.go": {mode: filemode.Regular, data: "package pkg\n"},
+	})
+
+	tests := []struct {
+		description string
+		hash        string
+		dir         string
+		expected    []string
+		expectedErr error
+	}{
+		{
+			description: "every file",
+			hash:        hash.String(),
+			expected:    []string{"meson.build", "sub/meson.build", "sub/pkg/version.go"},
+		}, {
+			description: "subdirectory",
+			hash:        hash.String(),
+			dir:         "sub",
+			expected:    []string{"sub/meson.build", "sub/pkg/version.go"},
+		}, {
+			description: "missing directory",
+			hash:        hash.String(),
+			dir:         "docs",
+		}, {
+			description: "missing commit",
+			hash:        "0123456789012345678901234567890123456789",
+			expectedErr: ErrCommitMissing,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			assert := assert.New(t)
+
+			g := &Git{repo: repo}
+			got, err := g.ListFiles(tc.hash, tc.dir)
+			if tc.expectedErr != nil {
+				assert.True(errors.Is(err, tc.expectedErr),
+					fmt.Errorf("error [%v] doesn't contain error [%v] in its err chain",
+						err, tc.expectedErr),
+				)
+				return
+			}
+			assert.NoError(err)
+			assert.ElementsMatch(tc.expected, got)
+		})
+	}
+}
+
+func TestCommitToBranch(t *testing.T) {
+	tests := []struct {
+		description string
+		opts        Options
+		expected    string
+	}{
+		{
+			description: "head committer",
+			expected:    "GitHub <noreply@github.com>",
+		}, {
+			description: "configured identity",
+			opts:        Options{TaggerName: "Releaser", TaggerEmail: "releaser@example.com"},
+			expected:    "Releaser <releaser@example.com>",
+		}, {
+			description: "configured name",
+			opts:        Options{TaggerName: "Releaser"},
+			expected:    "Releaser <noreply@github.com>",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			assert := assert.New(t)
+
+			fs := memfs.New()
+			repo, err := git.Init(memory.NewStorage(), fs)
+			require.NoError(t, err)
+			wt, err := repo.Worktree()
+			require.NoError(t, err)
+
+			require.NoError(t, util.WriteFile(fs, "CHANGELOG.md", []byte("# Changelog\n"), 0644))
+			_, err = wt.Add("CHANGELOG.md")
+			require.NoError(t, err)
+			_, err = wt.Commit("Initial", &git.CommitOptions{
+				Author: &object.Signature{Name: "GitHub", Email: "noreply@github.com", When: testSig.When},
+			})
+			require.NoError(t, err)
+
+			require.NoError(t, util.WriteFile(fs, "CHANGELOG.md", []byte("# Changelog\n\n## [v1.0.0]\n"), 0644))
+			g := &Git{repo: repo, opts: tc.opts}
+			hash, err := g.CommitToBranch("release/v1.0.0", "Prepare the release: v1.0.0", "CHANGELOG.md")
+			require.NoError(t, err)
+
+			commit, err := repo.CommitObject(plumbing.NewHash(hash))
+			require.NoError(t, err)
+			assert.Equal(tc.expected, commit.Author.String())
+			assert.Equal(tc.expected, commit.Committer.String())
+
+			head, err := repo.Head()
+			require.NoError(t, err)
+			assert.Equal("refs/heads/release/v1.0.0", head.Name().String())
+		})
+	}
+}
+
+func TestMoveTag(t *testing.T) {
+	assert := assert.New(t)
+
+	repo, err := git.Init(memory.NewStorage(), nil)
+	require.NoError(t, err)
+	first := storeCommit(t, repo, map[string]testFile{
+		"main.c": {mode: filemode.Regular, data: "int main;\n"},
+	})
+	second := storeCommit(t, repo, map[string]testFile{
+		"main.c": {mode: filemode.Regular, data: "int main(void);\n"},
+	})
+	g := &Git{repo: repo}
+
+	prev, err := g.MoveTag("v1", "Latest release: v1.0.0", first.String())
+	require.NoError(t, err)
+	assert.Empty(prev)
+
+	moved, err := g.MoveTag("v1", "Latest release: v1.1.0", second.String())
+	require.NoError(t, err)
+	assert.NotEmpty(moved)
+
+	info, err := g.InspectTag("v1")
+	require.NoError(t, err)
+	assert.Equal(second.String(), info.Commit)
+
+	require.NoError(t, g.RestoreTag("v1", moved))
+	info, err = g.InspectTag("v1")
+	require.NoError(t, err)
+	assert.Equal(first.String(), info.Commit)
+
+	require.NoError(t, g.RestoreTag("v1", ""))
+	_, err = g.InspectTag("v1")
+	assert.ErrorIs(err, ErrTagNotFound)
+
+	_, err = g.MoveTag("v1", "Latest release: v1.2.0", "0123456789012345678901234567890123456789")
+	assert.Error(err)
+}
+
+func TestPushAliasTags(t *testing.T) {
+	assert := assert.New(t)
+
+	dir := t.TempDir()
+	_, err := git.PlainInit(dir, true)
+	require.NoError(t, err)
+
+	repo, err := git.Init(memory.NewStorage(), nil)
+	require.NoError(t, err)
+	_, err = repo.CreateRemote(&config.RemoteConfig{Name: "origin", URLs: []string{dir}})
+	require.NoError(t, err)
+	first := storeCommit(t, repo, map[string]testFile{
+		"main.c": {mode: filemode.Regular, data: "int main;\n"},
+	})
+	second := storeCommit(t, repo, map[string]testFile{
+		"main.c": {mode: filemode.Regular, data: "int main(void);\n"},
+	})
+	g := &Git{repo: repo}
+
+	for _, hash := range []string{first.String(), second.String()} {
+		_, err = g.MoveTag("v1", "Latest release", hash)
+		require.NoError(t, err)
+		require.NoError(t, g.PushAliasTags(""))
+		require.NoError(t, g.PushAliasTags("", "v1"))
+
+		remote, err := g.RemoteTags("")
+		require.NoError(t, err)
+		assert.Equal(hash, remote["v1"].Commit)
+	}
+}
+
+// newPushRepo returns an in memory repo with a single commit on the main
+// branch and the upstream repo in the dir as its origin.
+func newPushRepo(t *testing.T, dir, msg string) (*Git, string) {
+	t.Helper()
+
+	repo, err := git.Init(memory.NewStorage(), nil)
+	require.NoError(t, err)
+	_, err = repo.CreateRemote(&config.RemoteConfig{Name: "origin", URLs: []string{dir}})
+	require.NoError(t, err)
+
+	obj := repo.Storer.NewEncodedObject()
+	require.NoError(t, (&object.Tree{}).Encode(obj))
+	tree, err := repo.Storer.SetEncodedObject(obj)
+	require.NoError(t, err)
+
+	sig := object.Signature{
+		Name:  "Test",
+		Email: "test@example.com",
+		When:  time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC),
+	}
+	commit := &object.Commit{
+		Author:    sig,
+		Committer: sig,
+		Message:   msg,
+		TreeHash:  tree,
+	}
+	obj = repo.Storer.NewEncodedObject()
+	require.NoError(t, commit.Encode(obj))
+	hash, err := repo.Storer.SetEncodedObject(obj)
+	require.NoError(t, err)
+	require.NoError(t, repo.Storer.SetReference(plumbing.NewHashReference("refs/heads/main", hash)))
+
+	return &Git{repo: repo}, hash.String()
+}
+
+func TestPushTags(t *testing.T) {
+	assert := assert.New(t)
+
+	dir := t.TempDir()
+	_, err := git.PlainInit(dir, true)
+	require.NoError(t, err)
+
+	g, hash := newPushRepo(t, dir, "test\n")
+	require.NoError(t, g.PushBranch("", "main"))
+
+	// Only the named tags are pushed.
+	require.NoError(t, g.TagCommit("v1.0.0", "Releasing: v1.0.0", hash))
+	require.NoError(t, g.TagCommit("v1.1.0", "Releasing: v1.1.0", hash))
+	require.NoError(t, g.PushTags("", "v1.0.0"))
+	require.NoError(t, g.PushTags(""))
+
+	remote, err := g.RemoteTags("")
+	require.NoError(t, err)
+	local, err := g.repo.Tag("v1.0.0")
+	require.NoError(t, err)
+	assert.Equal(map[string]TagRef{
+		"v1.0.0": {Object: local.Hash().String(), Commit: hash},
+	}, remote)
+
+	// Pushing the same tag again changes nothing.
+	require.NoError(t, g.PushTags("", "v1.0.0"))
+
+	// Another run tagged a different commit, so nothing is pushed and the
+	// release can be undone.
+	other, otherHash := newPushRepo(t, dir, "other\n")
+	require.NoError(t, other.TagCommit("v1.0.0", "Releasing: v1.0.0", otherHash))
+	require.NoError(t, other.TagCommit("v1.1.0", "Releasing: v1.1.0", otherHash))
+	err = other.PushTags("", "v1.1.0", "v1.0.0")
+	assert.ErrorIs(err, ErrTagRace)
+	assert.NotErrorIs(err, ErrPushIncomplete)
+
+	// Another run tagged the same commit with a different tag object.
+	same, _ := newPushRepo(t, dir, "test\n")
+	same.opts.TaggerName = "Other"
+	require.NoError(t, same.TagCommit("v1.0.0", "Releasing: v1.0.0", hash))
+	assert.ErrorIs(same.PushTags("", "v1.0.0"), ErrTagRace)
+
+	remote, err = g.RemoteTags("")
+	require.NoError(t, err)
+	assert.Len(remote, 1)
+	assert.Equal(local.Hash().String(), remote["v1.0.0"].Object)
+}
+
+func TestVerifyRemoteTags(t *testing.T) {
+	dir := t.TempDir()
+	_, err := git.PlainInit(dir, true)
+	require.NoError(t, err)
+
+	g, hash := newPushRepo(t, dir, "test\n")
+	require.NoError(t, g.PushBranch("", "main"))
+	require.NoError(t, g.TagCommit("v1.0.0", "Releasing: v1.0.0", hash))
+	require.NoError(t, g.PushTags("", "v1.0.0"))
+
+	// Another run tagged a different commit with the same names.
+	other, otherHash := newPushRepo(t, dir, "other\n")
+	require.NoError(t, other.TagCommit("v1.0.0", "Releasing: v1.0.0", otherHash))
+	require.NoError(t, other.TagCommit("v1.1.0", "Releasing: v1.1.0", otherHash))
+
+	tests := []struct {
+		description string
+		g           *Git
+		tags        []string
+		expectedErr error
+	}{
+		{
+			description: "matches",
+			g:           g,
+			tags:        []string{"v1.0.0"},
+		}, {
+			description: "different object",
+			g:           other,
+			tags:        []string{"v1.0.0"},
+			expectedErr: ErrRemoteTagMismatch,
+		}, {
+			description: "missing",
+			g:           other,
+			tags:        []string{"v1.1.0"},
+			expectedErr: ErrRemoteTagMismatch,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			assert := assert.New(t)
+
+			err := tc.g.verifyRemoteTags("", tc.tags)
+			if tc.expectedErr != nil {
+				assert.True(errors.Is(err, tc.expectedErr),
+					fmt.Errorf("error [%v] doesn't contain error [%v] in its err chain",
+						err, tc.expectedErr),
+				)
+				return
+			}
+			assert.NoError(err)
+		})
+	}
+}
@@ -0,0 +1,120 @@
+// SPDX-FileCopyrightText: 2021 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+package git
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/go-git/go-billy/v5"
+	"github.com/go-git/go-git/v5/storage"
+	"github.com/go-git/go-git/v5/storage/filesystem"
+)
+
+const (
+	// lfsPointerMax is the largest size of a Git LFS pointer file.
+	lfsPointerMax = 1024
+
+	lfsObjectsDir = "lfs/objects"
+)
+
+var (
+	ErrLFSObjectMissing = errors.New("the Git LFS object is not available locally, fetch it with 'git lfs pull' or 'lfs: true' in actions/checkout")
+	ErrLFSObjectInvalid = errors.New("the Git LFS object doesn't match its pointer")
+	ErrLFSUnsupported   = errors.New("the repository storage has no Git LFS objects directory")
+)
+
+// lfsPointerVersions are the versions of the pointer file spec.
+var lfsPointerVersions = []string{
+	"https://git-lfs.github.com/spec/v1",
+	"https://hawser.github.com/spec/v1",
+}
+
+// lfsPointer is the object a Git LFS pointer file refers to.
+type lfsPointer struct {
+	oid  string
+	size int64
+}
+
+// parseLFSPointer returns the pointer held in the data, or false if the data
+// isn't a pointer file.
+func parseLFSPointer(data []byte) (lfsPointer, bool) {
+	var p lfsPointer
+	if len(data) > lfsPointerMax {
+		return p, false
+	}
+
+	var version, size bool
+	s := bufio.NewScanner(bytes.NewReader(data))
+	for s.Scan() {
+		key, value, found := strings.Cut(s.Text(), " ")
+		if !found {
+			return p, false
+		}
+
+		switch key {
+		case "version":
+			for _, v := range lfsPointerVersions {
+				version = version || value == v
+			}
+		case "oid":
+			oid, found := strings.CutPrefix(value, "sha256:")
+			if _, err := hex.DecodeString(oid); !found || len(oid) != 2*sha256.Size || err != nil {
+				return p, false
+			}
+			p.oid = strings.ToLower(oid)
+		case "size":
+			n, err := strconv.ParseInt(value, 10, 64)
+			if err != nil || n < 0 {
+				return p, false
+			}
+			p.size, size = n, true
+		}
+	}
+
+	return p, version && size && p.oid != ""
+}
+
+// lfsObjects returns the directory of the Git LFS objects of the repo.
+func lfsObjects(s storage.Storer) (billy.Filesystem, error) {
+	fs, ok := s.(*filesystem.Storage)
+	if !ok {
+		return nil, ErrLFSUnsupported
+	}
+
+	return fs.Filesystem().Chroot(lfsObjectsDir)
+}
+
+// readLFSObject returns the contents of the object the pointer refers to
+// after checking the size and hash.
+func readLFSObject(objects billy.Filesystem, p lfsPointer, name string) ([]byte, error) {
+	file := objects.Join(p.oid[0:2], p.oid[2:4], p.oid)
+	f, err := objects.Open(file)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("%w: '%s' (sha256:%s)", ErrLFSObjectMissing, name, p.oid)
+		}
+		return nil, fmt.Errorf("%w: unable to read the Git LFS object of '%s'", err, name)
+	}
+	defer f.Close()
+
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return nil, fmt.Errorf("%w: unable to read the Git LFS object of '%s'", err, name)
+	}
+
+	sum := sha256.Sum256(data)
+	if int64(len(data)) != p.size || hex.EncodeToString(sum[:]) != p.oid {
+		return nil, fmt.Errorf("%w: '%s' (sha256:%s)", ErrLFSObjectInvalid, name, p.oid)
+	}
+
+	return data, nil
+}
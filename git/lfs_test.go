@@ -0,0 +1,161 @@
+// SPDX-FileCopyrightText: 2021 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+package git
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"testing"
+
+	"github.com/go-git/go-billy/v5/memfs"
+	"github.com/go-git/go-billy/v5/util"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/cache"
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+	"github.com/go-git/go-git/v5/storage/filesystem"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// lfsPointerFile returns the pointer file of the contents.
+func lfsPointerFile(contents string) (string, string) {
+	sum := sha256.Sum256([]byte(contents))
+	oid := hex.EncodeToString(sum[:])
+	return fmt.Sprintf("version https://git-lfs.github.com/spec/v1\noid sha256:%s\nsize %d\n", oid, len(contents)), oid
+}
+
+func TestParseLFSPointer(t *testing.T) {
+	pointer, oid := lfsPointerFile("firmware")
+
+	tests := []struct {
+		description string
+		data        string
+		expected    lfsPointer
+		expectedOK  bool
+	}{
+		{
+			description: "pointer",
+			data:        pointer,
+			expected:    lfsPointer{oid: oid, size: 8},
+			expectedOK:  true,
+		}, {
+			description: "pointer with extensions",
+			data: "version https://git-lfs.github.com/spec/v1\n" +
+				"ext-0-foo sha256:" + oid + "\n" +
+				"oid sha256:" + oid + "\nsize 8\n",
+			expected:   lfsPointer{oid: oid, size: 8},
+			expectedOK: true,
+		}, {
+			description: "not a pointer",
+			data:        "int main;\n",
+		}, {
+			description: "unknown version",
+			data:        "version https://example.com/v9\noid sha256:" + oid + "\nsize 8\n",
+		}, {
+			description: "short oid",
+			data:        "version https://git-lfs.github.com/spec/v1\noid sha256:abc\nsize 8\n",
+		}, {
+			description: "missing size",
+			data:        "version https://git-lfs.github.com/spec/v1\noid sha256:" + oid + "\n",
+		}, {
+			description: "too large",
+			data:        pointer + string(make([]byte, lfsPointerMax)),
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			got, ok := parseLFSPointer([]byte(tc.data))
+			assert.Equal(t, tc.expectedOK, ok)
+			if tc.expectedOK {
+				assert.Equal(t, tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestCreateArchiveLFS(t *testing.T) {
+	pointer, oid := lfsPointerFile("firmware")
+	files := map[string]testFile{
+		".gitattributes": {mode: filemode.Regular, data: "*.bin filter=lfs diff=lfs merge=lfs -text\n"},
+		"fw.bin":         {mode: filemode.Regular, data: pointer},
+		"raw.bin":        {mode: filemode.Regular, data: "not a pointer\n"},
+		"pointer.txt":    {mode: filemode.Regular, data: pointer},
+	}
+
+	tests := []struct {
+		description string
+		lfs         bool
+		object      string
+		expected    string
+		expectedLFS []string
+		expectedErr error
+	}{
+		{
+			description: "pointers kept",
+			object:      "firmware",
+			expected:    pointer,
+		}, {
+			description: "objects embedded",
+			lfs:         true,
+			object:      "firmware",
+			expected:    "firmware",
+			expectedLFS: []string{"fw.bin"},
+		}, {
+			description: "object missing",
+			lfs:         true,
+			expectedErr: ErrLFSObjectMissing,
+		}, {
+			description: "object corrupt",
+			lfs:         true,
+			object:      "firmwar3",
+			expectedErr: ErrLFSObjectInvalid,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			assert := assert.New(t)
+
+			dot := memfs.New()
+			repo, err := git.Init(filesystem.NewStorage(dot, cache.NewObjectLRUDefault()), nil)
+			require.NoError(t, err)
+			hash := storeCommit(t, repo, files)
+			_, err = repo.CreateTag("v1.0.0", hash, &git.CreateTagOptions{
+				Tagger:  &testSig,
+				Message: "Releasing: v1.0.0",
+			})
+			require.NoError(t, err)
+			if tc.object != "" {
+				file := "lfs/objects/" + oid[0:2] + "/" + oid[2:4] + "/" + oid
+				require.NoError(t, util.WriteFile(dot, file, []byte(tc.object), 0644))
+			}
+
+			g := &Git{repo: repo, opts: Options{LFS: tc.lfs}}
+			a, err := g.CreateArchive("foo", "v1.0.0", "", Format{Name: FormatTar}, t.TempDir(), nil)
+			if tc.expectedErr != nil {
+				assert.ErrorIs(err, tc.expectedErr)
+				assert.ErrorContains(err, "fw.bin")
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(tc.expectedLFS, a.LFS)
+
+			entries, _ := readTar(t, a.File, FormatTar)
+			assert.Equal(tc.expected, entries["foo/fw.bin"].data)
+			assert.Equal("not a pointer\n", entries["foo/raw.bin"].data)
+			assert.Equal(pointer, entries["foo/pointer.txt"].data)
+		})
+	}
+}
+
+func TestCreateArchiveLFSUnsupported(t *testing.T) {
+	g := newTestRepo(t, "v1.0.0", map[string]testFile{
+		"main.c": {mode: filemode.Regular, data: "int main;\n"},
+	})
+	g.opts.LFS = true
+
+	_, err := g.CreateArchive("foo", "v1.0.0", "", Format{Name: FormatTar}, t.TempDir(), nil)
+	assert.ErrorIs(t, err, ErrLFSUnsupported)
+}
@@ -0,0 +1,350 @@
+// SPDX-FileCopyrightText: 2021 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+package git
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/armor"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/storage/memory"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ssh"
+)
+
+// testKey is a signing key along with its allowed signers entry and the
+// description VerifyTag reports for it.
+type testKey struct {
+	format     string
+	private    string
+	passphrase string
+	allowed    string
+	expected   string
+}
+
+func newPGPKey(t *testing.T, passphrase string) testKey {
+	t.Helper()
+
+	entity, err := openpgp.NewEntity("Test", "", "test@example.com", nil)
+	require.NoError(t, err)
+
+	var pub bytes.Buffer
+	w, err := armor.Encode(&pub, openpgp.PublicKeyType, nil)
+	require.NoError(t, err)
+	require.NoError(t, entity.Serialize(w))
+	require.NoError(t, w.Close())
+
+	if passphrase != "" {
+		require.NoError(t, entity.EncryptPrivateKeys([]byte(passphrase), nil))
+	}
+
+	var priv bytes.Buffer
+	w, err = armor.Encode(&priv, openpgp.PrivateKeyType, nil)
+	require.NoError(t, err)
+	require.NoError(t, entity.SerializePrivateWithoutSigning(w, nil))
+	require.NoError(t, w.Close())
+
+	return testKey{
+		format:     SigningOpenPGP,
+		private:    priv.String(),
+		passphrase: passphrase,
+		allowed:    pub.String(),
+		expected:   fmt.Sprintf("OpenPGP key %X", entity.PrimaryKey.Fingerprint),
+	}
+}
+
+func newSSHKey(t *testing.T, priv crypto.Signer) testKey {
+	t.Helper()
+
+	block, err := ssh.MarshalPrivateKey(priv, "test")
+	require.NoError(t, err)
+
+	pub, err := ssh.NewPublicKey(priv.Public())
+	require.NoError(t, err)
+
+	return testKey{
+		format:   SigningSSH,
+		private:  string(pem.EncodeToMemory(block)),
+		allowed:  "test@example.com " + string(ssh.MarshalAuthorizedKey(pub)),
+		expected: "SSH key " + ssh.FingerprintSHA256(pub),
+	}
+}
+
+// newSigningRepo returns a repo with a single commit at the head.
+func newSigningRepo(t *testing.T, opts Options) *Git {
+	t.Helper()
+
+	repo, err := git.Init(memory.NewStorage(), nil)
+	require.NoError(t, err)
+
+	obj := repo.Storer.NewEncodedObject()
+	require.NoError(t, (&object.Tree{}).Encode(obj))
+	tree, err := repo.Storer.SetEncodedObject(obj)
+	require.NoError(t, err)
+
+	sig := object.Signature{
+		Name:  "Test",
+		Email: "test@example.com",
+		When:  time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC),
+	}
+	commit := &object.Commit{
+		Author:    sig,
+		Committer: sig,
+		Message:   "test\n",
+		TreeHash:  tree,
+	}
+	obj = repo.Storer.NewEncodedObject()
+	require.NoError(t, commit.Encode(obj))
+	hash, err := repo.Storer.SetEncodedObject(obj)
+	require.NoError(t, err)
+	require.NoError(t, repo.Storer.SetReference(plumbing.NewHashReference("refs/heads/master", hash)))
+
+	g := &Git{repo: repo, opts: opts}
+	if opts.SigningKey != "" {
+		g.signer, err = newSigner(opts.SigningFormat, opts.SigningKey, opts.SigningPassphrase)
+		require.NoError(t, err)
+	}
+	g.allowed, err = parseAllowedSigners(opts.AllowedSigners)
+	require.NoError(t, err)
+
+	return g
+}
+
+func TestSignedTags(t *testing.T) {
+	edKey := func(t *testing.T) testKey {
+		_, priv, err := ed25519.GenerateKey(rand.Reader)
+		require.NoError(t, err)
+		return newSSHKey(t, priv)
+	}
+	ecKey := func(t *testing.T) testKey {
+		priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		require.NoError(t, err)
+		return newSSHKey(t, priv)
+	}
+	rsaKey := func(t *testing.T) testKey {
+		priv, err := rsa.GenerateKey(rand.Reader, 2048)
+		require.NoError(t, err)
+		return newSSHKey(t, priv)
+	}
+	pgpKey := func(t *testing.T) testKey {
+		return newPGPKey(t, "")
+	}
+
+	tests := []struct {
+		description string
+		key         func(*testing.T) testKey
+		passphrase  string
+		detect      bool
+		other       bool
+		tamper      bool
+		expectedErr error
+	}{
+		{
+			description: "openpgp",
+			key:         pgpKey,
+		}, {
+			description: "openpgp with a passphrase",
+			key: func(t *testing.T) testKey {
+				return newPGPKey(t, "secret")
+			},
+		}, {
+			description: "openpgp detected",
+			key:         pgpKey,
+			detect:      true,
+		}, {
+			description: "ssh ed25519",
+			key:         edKey,
+		}, {
+			description: "ssh ed25519 detected",
+			key:         edKey,
+			detect:      true,
+		}, {
+			description: "ssh ecdsa",
+			key:         ecKey,
+		}, {
+			description: "ssh rsa",
+			key:         rsaKey,
+		}, {
+			description: "openpgp key not allowed",
+			key:         pgpKey,
+			other:       true,
+			expectedErr: ErrTagSignature,
+		}, {
+			description: "ssh key not allowed",
+			key:         edKey,
+			other:       true,
+			expectedErr: ErrTagSignature,
+		}, {
+			description: "openpgp tampered",
+			key:         pgpKey,
+			tamper:      true,
+			expectedErr: ErrTagSignature,
+		}, {
+			description: "ssh tampered",
+			key:         ecKey,
+			tamper:      true,
+			expectedErr: ErrTagSignature,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			assert := assert.New(t)
+
+			key := tc.key(t)
+			allowed := key.allowed
+			if tc.other {
+				allowed = tc.key(t).allowed
+			}
+			opts := Options{
+				SigningFormat:     key.format,
+				SigningKey:        key.private,
+				SigningPassphrase: key.passphrase,
+				AllowedSigners:    allowed,
+			}
+			if tc.detect {
+				opts.SigningFormat = ""
+			}
+
+			g := newSigningRepo(t, opts)
+			require.NoError(t, g.TagHead("v1.0.0", "Releasing: v1.0.0"))
+			if tc.tamper {
+				tamperTag(t, g, "v1.0.0")
+			}
+
+			got, err := g.VerifyTag("v1.0.0")
+			if tc.expectedErr != nil {
+				assert.True(errors.Is(err, tc.expectedErr),
+					fmt.Errorf("error [%v] doesn't contain error [%v] in its err chain",
+						err, tc.expectedErr),
+				)
+				return
+			}
+			assert.NoError(err)
+			assert.Equal(key.expected, got)
+		})
+	}
+}
+
+// tamperTag changes the message of the signed tag while keeping the
+// signature.
+func tamperTag(t *testing.T, g *Git, tag string) {
+	t.Helper()
+
+	ref, err := g.repo.Tag(tag)
+	require.NoError(t, err)
+	obj, err := g.repo.TagObject(ref.Hash())
+	require.NoError(t, err)
+
+	obj.Message = "Releasing: something else\n"
+	encoded := g.repo.Storer.NewEncodedObject()
+	require.NoError(t, obj.Encode(encoded))
+	hash, err := g.repo.Storer.SetEncodedObject(encoded)
+	require.NoError(t, err)
+	require.NoError(t, g.repo.Storer.SetReference(plumbing.NewHashReference(ref.Name(), hash)))
+}
+
+func TestVerifyTagUnsigned(t *testing.T) {
+	key := newPGPKey(t, "")
+
+	tests := []struct {
+		description string
+		allowed     string
+		lightweight bool
+		missing     bool
+		expectedErr error
+	}{
+		{
+			description: "annotated",
+			allowed:     key.allowed,
+			expectedErr: ErrTagUnsigned,
+		}, {
+			description: "lightweight",
+			allowed:     key.allowed,
+			lightweight: true,
+			expectedErr: ErrTagUnsigned,
+		}, {
+			description: "missing",
+			allowed:     key.allowed,
+			missing:     true,
+			expectedErr: ErrTagNotFound,
+		}, {
+			description: "no allowed keys",
+			expectedErr: ErrTagSignature,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			g := newSigningRepo(t, Options{AllowedSigners: tc.allowed})
+			switch {
+			case tc.missing:
+			case tc.lightweight:
+				head, err := g.repo.Head()
+				require.NoError(t, err)
+				_, err = g.repo.CreateTag("v1.0.0", head.Hash(), nil)
+				require.NoError(t, err)
+			default:
+				require.NoError(t, g.TagHead("v1.0.0", "Releasing: v1.0.0"))
+			}
+
+			_, err := g.VerifyTag("v1.0.0")
+			assert.ErrorIs(t, err, tc.expectedErr)
+		})
+	}
+}
+
+func TestNewSigner(t *testing.T) {
+	key := newPGPKey(t, "secret")
+
+	tests := []struct {
+		description string
+		format      string
+		key         string
+		passphrase  string
+		expectedErr error
+	}{
+		{
+			description: "unknown format",
+			format:      "x509",
+			key:         key.private,
+			expectedErr: ErrSigningFormat,
+		}, {
+			description: "wrong passphrase",
+			key:         key.private,
+			passphrase:  "wrong",
+			expectedErr: ErrSigningKey,
+		}, {
+			description: "public key",
+			format:      SigningOpenPGP,
+			key:         key.allowed,
+			expectedErr: ErrSigningKey,
+		}, {
+			description: "invalid ssh key",
+			key:         "not a key",
+			expectedErr: ErrSigningKey,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			_, err := newSigner(tc.format, tc.key, tc.passphrase)
+			assert.ErrorIs(t, err, tc.expectedErr)
+		})
+	}
+}
@@ -3,15 +3,31 @@
 package main
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
 	"os"
+	"strconv"
+	"strings"
+	"unicode"
 
+	gh "github.com/sethvargo/go-githubactions"
+	"github.com/xmidt-org/release-builder-action/git"
 	"github.com/xmidt-org/release-builder-action/project"
 )
 
+const (
+	modeRelease = "release"
+	modeLint    = "lint"
+	modeLinks   = "fix-links"
+	modePrepare = "prepare"
+	modeVerify  = "verify-reproducible"
+)
+
 var (
 	errBoolFormatError = errors.New("the string should be 'true' or 'false'")
+	errModeUnknown     = errors.New("the mode is unknown")
+	errComponentsJSON  = errors.New("the components must be a JSON list")
 )
 
 func main() {
@@ -19,13 +35,50 @@ func main() {
 }
 
 func run() int {
-	p, err := parseAndValidateInput()
+	mode := os.Getenv("INPUTS_MODE")
+	if mode == "" {
+		mode = modeRelease
+	}
+
+	p, err := parseAndValidateInput(mode)
 	if err != nil {
 		Err("Error validating input: %s", err)
 		return 1
 	}
 
-	err = p.ExamineProject()
+	switch mode {
+	case modeLint:
+		return lint(p)
+	case modeLinks:
+		if err := p.FixLinks(); err != nil {
+			Err("Error fixing the changelog links: %s", err)
+			return 1
+		}
+		return 0
+	case modePrepare:
+		if err := p.Prepare(); err != nil {
+			Err("Error prepairing the release: %s", err)
+			return 1
+		}
+		if err := p.OutputData(); err != nil {
+			Err("Error outputing: %s", err)
+			return 1
+		}
+		return 0
+	case modeVerify:
+		if err := p.VerifyReproducible(); err != nil {
+			Err("Error verifying the archives: %s", err)
+			return 1
+		}
+		Info("The archives are reproducible.")
+		return 0
+	}
+
+	return release(p)
+}
+
+func release(p *project.Project) int {
+	err := p.ExamineProject()
 	if err != nil {
 		Err("Error examining project: %s", err)
 		return 1
@@ -46,14 +99,95 @@ func run() int {
 	return 0
 }
 
-func parseAndValidateInput() (*project.Project, error) {
-	dryrun := false
-	switch os.Getenv("INPUTS_DRY_RUN") {
+func lint(p *project.Project) int {
+	problems, err := p.Lint()
+	if err != nil {
+		Err("Error linting: %s", err)
+		return 1
+	}
+
+	if err = p.OutputData(); err != nil {
+		Err("Error outputing: %s", err)
+		return 1
+	}
+
+	for _, problem := range problems {
+		gh.WithFieldsMap(map[string]string{
+			"file": problem.File,
+			"line": strconv.Itoa(problem.Line),
+		}).Errorf("%s", problem.Message)
+	}
+
+	if len(problems) > 0 {
+		Err("Found %d problem(s) with the changelog.", len(problems))
+		return 1
+	}
+
+	Info("No problems found with the changelog.")
+	return 0
+}
+
+func parseBool(name string) (bool, error) {
+	switch os.Getenv(name) {
 	case "true":
-		dryrun = true
+		return true, nil
 	case "false":
+		return false, nil
+	}
+
+	return false, fmt.Errorf("%w: %s", errBoolFormatError, name)
+}
+
+func parseAndValidateInput(mode string) (*project.Project, error) {
+	dryrun, err := parseBool("INPUTS_DRY_RUN")
+	if err != nil {
+		return nil, err
+	}
+
+	switch mode {
+	case modeRelease, modePrepare:
+	case modeLint, modeVerify:
+		// Linting and verifying never alter the repo.
+		dryrun = true
+	case modeLinks:
 	default:
-		return nil, errBoolFormatError
+		return nil, fmt.Errorf("%w: '%s'", errModeUnknown, mode)
+	}
+
+	releaseAll, err := parseBool("INPUTS_RELEASE_ALL")
+	if err != nil {
+		return nil, err
+	}
+
+	submodules, err := parseBool("INPUTS_SUBMODULES")
+	if err != nil {
+		return nil, err
+	}
+
+	lfs, err := parseBool("INPUTS_LFS")
+	if err != nil {
+		return nil, err
+	}
+
+	initialDev, err := parseBool("INPUTS_INITIAL_DEVELOPMENT")
+	if err != nil {
+		return nil, err
+	}
+
+	var components []project.Component
+	if s := os.Getenv("INPUTS_COMPONENTS"); s != "" {
+		if err = json.Unmarshal([]byte(s), &components); err != nil {
+			return nil, fmt.Errorf("%w: %s", errComponentsJSON, err)
+		}
+	}
+
+	var archives []git.Format
+	for _, s := range splitList(os.Getenv("INPUTS_ARCHIVE_FORMATS")) {
+		f, err := git.ParseFormat(s)
+		if err != nil {
+			return nil, err
+		}
+		archives = append(archives, f)
 	}
 
 	opts := project.ProjectOpts{
@@ -64,10 +198,43 @@ func parseAndValidateInput() (*project.Project, error) {
 		ChangelogFile: os.Getenv("INPUTS_CHANGELOG"),
 		ArtifactDir:   os.Getenv("INPUTS_ARTIFACT_DIR"),
 		SHASumFile:    os.Getenv("INPUTS_SHASUM_FILE"),
+		ReleaseAll:    releaseAll,
+		TagTarget:     os.Getenv("INPUTS_TAG_TARGET"),
+		Ref:           os.Getenv("INPUTS_REF"),
+		LocalOnly:     mode != modeRelease && mode != modePrepare,
 		Log:           Info,
 		Meson: project.Meson{
-			Provides: os.Getenv("INPUTS_MESON_PROVIDES"),
+			Provides:     os.Getenv("INPUTS_MESON_PROVIDES"),
+			VersionCheck: os.Getenv("INPUTS_MESON_VERSION_CHECK"),
+			Archive:      os.Getenv("INPUTS_MESON_ARCHIVE"),
+		},
+		Archives:   archives,
+		Submodules: submodules,
+		LFS:        lfs,
+		Dist: project.Dist{
+			Overlay:      os.Getenv("INPUTS_DIST_OVERLAY"),
+			VersionFiles: splitList(os.Getenv("INPUTS_DIST_VERSION_FILES")),
+			MetadataFile: os.Getenv("INPUTS_DIST_METADATA_FILE"),
+		},
+		VersionFiles: splitList(os.Getenv("INPUTS_VERSION_FILES")),
+		AliasTags:    splitList(os.Getenv("INPUTS_ALIAS_TAGS")),
+		Tagging: git.Options{
+			TaggerName:        os.Getenv("INPUTS_TAGGER_NAME"),
+			TaggerEmail:       os.Getenv("INPUTS_TAGGER_EMAIL"),
+			SigningFormat:     os.Getenv("INPUTS_SIGNING_FORMAT"),
+			SigningKey:        os.Getenv("INPUTS_SIGNING_KEY"),
+			SigningPassphrase: os.Getenv("INPUTS_SIGNING_PASSPHRASE"),
+			AllowedSigners:    os.Getenv("INPUTS_ALLOWED_SIGNERS"),
 		},
+		Prepare: project.Prepare{
+			Bump:         os.Getenv("INPUTS_BUMP"),
+			Version:      os.Getenv("INPUTS_VERSION"),
+			PrereleaseID: os.Getenv("INPUTS_PRERELEASE_ID"),
+			Component:    os.Getenv("INPUTS_COMPONENT"),
+		},
+		VerifyVersion:      os.Getenv("INPUTS_VERSION"),
+		InitialDevelopment: initialDev,
+		Components:         components,
 	}
 
 	p, err := project.NewProject(opts, dryrun)
@@ -78,6 +245,13 @@ func parseAndValidateInput() (*project.Project, error) {
 	return p, nil
 }
 
+// splitList splits a comma or space separated list.
+func splitList(s string) []string {
+	return strings.FieldsFunc(s, func(r rune) bool {
+		return r == ',' || unicode.IsSpace(r)
+	})
+}
+
 func Info(format string, v ...interface{}) {
 	fmt.Printf("\x1b[1;34m"+format+"\x1b[0m\n", v...)
 }
@@ -0,0 +1,106 @@
+// SPDX-FileCopyrightText: 2021 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+package project
+
+import (
+	"errors"
+	"fmt"
+)
+
+const (
+	// AliasMajor moves a tag like v3 to the newest v3.x.y release.
+	AliasMajor = "major"
+
+	// AliasMinor moves a tag like v3.0 to the newest v3.0.y release.
+	AliasMinor = "minor"
+)
+
+var (
+	errAliasUnknown = errors.New("the alias tags must be major or minor")
+)
+
+// validateAliases ensures every alias tag kind is known.
+func validateAliases(aliases []string) error {
+	for _, a := range aliases {
+		if a != AliasMajor && a != AliasMinor {
+			return fmt.Errorf("%w: '%s'", errAliasUnknown, a)
+		}
+	}
+	return nil
+}
+
+// aliasNames returns the names of the alias tags of the version.
+func (p *Project) aliasNames(v semver) []string {
+	var names []string
+	for _, a := range p.opts.AliasTags {
+		switch a {
+		case AliasMajor:
+			names = append(names, fmt.Sprintf("%s%d", p.opts.TagPrefix, v.Major))
+		case AliasMinor:
+			names = append(names, fmt.Sprintf("%s%d.%d", p.opts.TagPrefix, v.Major, v.Minor))
+		}
+	}
+	return names
+}
+
+// moveAliases points the alias tags at the releases that are the newest of
+// their line, which must already be tagged.  Prereleases never move an
+// alias, and neither does a release of an older line, like a v2.3.4 fix when
+// v2.4.0 is out.  The names of the moved tags are returned.
+func (p *Project) moveAliases(tx *transaction) ([]string, error) {
+	if len(p.opts.AliasTags) == 0 {
+		return nil, nil
+	}
+
+	tags, err := p.allTags()
+	if err != nil {
+		return nil, err
+	}
+
+	newest := map[string]semver{}
+	for _, tag := range tags {
+		tv, err := parseSemver(tag, p.opts.TagPrefix)
+		if err != nil || tv.IsPrerelease() {
+			continue
+		}
+		for _, name := range p.aliasNames(tv) {
+			if cur, found := newest[name]; !found || cur.Compare(tv) < 0 {
+				newest[name] = tv
+			}
+		}
+	}
+
+	var moved []string
+	for i := len(p.releases) - 1; i >= 0; i-- {
+		r := p.releases[i]
+		if r.version.IsPrerelease() {
+			continue
+		}
+
+		for _, name := range p.aliasNames(r.version) {
+			if v := newest[name]; v.Compare(r.version) != 0 {
+				p.opts.Log("Not moving %s to %s, %s is newer.", name, r.tag, p.opts.TagPrefix+v.String())
+				continue
+			}
+
+			commit, err := p.git.ResolveCommit(r.tag)
+			if err != nil {
+				return nil, err
+			}
+
+			p.opts.Log("Moving the tag %s to %s.", name, r.tag)
+			prev, err := p.git.MoveTag(name, "Latest release: "+r.tag, commit)
+			if err != nil {
+				return nil, err
+			}
+			tx.onRollback("the tag "+name, func() error {
+				return p.git.RestoreTag(name, prev)
+			})
+
+			r.aliases = append(r.aliases, name)
+			moved = append(moved, name)
+		}
+	}
+
+	return moved, nil
+}
@@ -0,0 +1,211 @@
+// SPDX-FileCopyrightText: 2021 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+package project
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	changelog "github.com/xmidt-org/gokeepachangelog"
+	"github.com/xmidt-org/release-builder-action/git"
+)
+
+func TestMoveAliases(t *testing.T) {
+	tests := []struct {
+		description string
+		aliases     []string
+		tags        []string
+		releases    []string
+		expected    []string
+		expectedLog string
+	}{
+		{
+			description: "not configured",
+			releases:    []string{"v1.1.0"},
+		}, {
+			description: "major and minor",
+			aliases:     []string{AliasMajor, AliasMinor},
+			tags:        []string{"v1.0.0", "v1.1.0", "v2.0.0-rc.1", "other"},
+			releases:    []string{"v1.1.0"},
+			expected:    []string{"v1", "v1.1"},
+		}, {
+			description: "older line",
+			aliases:     []string{AliasMajor, AliasMinor},
+			tags:        []string{"v1.1.0", "v1.1.1", "v1.2.0"},
+			releases:    []string{"v1.1.1"},
+			expected:    []string{"v1.1"},
+			expectedLog: "Not moving v1 to v1.1.1, v1.2.0 is newer.",
+		}, {
+			description: "prerelease",
+			aliases:     []string{AliasMajor},
+			tags:        []string{"v1.0.0", "v2.0.0-rc.1"},
+			releases:    []string{"v2.0.0-rc.1"},
+		}, {
+			description: "several releases",
+			aliases:     []string{AliasMajor, AliasMinor},
+			tags:        []string{"v1.0.0", "v1.1.0", "v1.2.0"},
+			releases:    []string{"v1.2.0", "v1.1.0"},
+			expected:    []string{"v1.1", "v1", "v1.2"},
+			expectedLog: "Not moving v1 to v1.1.0, v1.2.0 is newer.",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			assert := assert.New(t)
+
+			mockGit := &mockGit{}
+			if tc.aliases != nil {
+				mockGit.On("Tags").Return(tc.tags, nil)
+			}
+
+			var releases []*release
+			for _, tag := range tc.releases {
+				v, err := parseSemver(tag, "v")
+				require.NoError(t, err)
+				releases = append(releases, &release{version: v, tag: tag})
+				mockGit.On("ResolveCommit", tag).Return("commit-"+tag, nil).Maybe()
+			}
+			for _, name := range tc.expected {
+				mockGit.On("MoveTag", name, mock.Anything, mock.Anything).Return("old-"+name, nil)
+				mockGit.On("RestoreTag", name, "old-"+name).Return(nil)
+			}
+
+			var logs []string
+			log := func(format string, v ...interface{}) {
+				logs = append(logs, fmt.Sprintf(format, v...))
+			}
+			p := &Project{
+				opts: ProjectOpts{
+					TagPrefix: "v",
+					AliasTags: tc.aliases,
+					Log:       log,
+				},
+				releases: releases,
+				git:      mockGit,
+			}
+
+			tx := &transaction{log: log}
+			moved, err := p.moveAliases(tx)
+			assert.NoError(err)
+			assert.Equal(tc.expected, moved)
+			if tc.expectedLog != "" {
+				assert.Contains(logs, tc.expectedLog)
+			}
+
+			var got []string
+			for _, r := range releases {
+				got = append(got, r.aliases...)
+			}
+			assert.ElementsMatch(tc.expected, got)
+
+			// A rollback points every alias back where it was.
+			errTest := errors.New("test error")
+			assert.ErrorIs(tx.rollback(errTest), errTest)
+			mockGit.AssertExpectations(t)
+		})
+	}
+}
+
+func TestMoveAliasesTarget(t *testing.T) {
+	mockGit := &mockGit{}
+	mockGit.On("Tags").Return([]string{"v1.1.0"}, nil)
+	mockGit.On("ResolveCommit", "v1.1.0").Return("abc", nil)
+	mockGit.On("MoveTag", "v1", "Latest release: v1.1.0", "abc").Return("", nil)
+
+	p := &Project{
+		opts: ProjectOpts{
+			TagPrefix: "v",
+			AliasTags: []string{AliasMajor},
+			Log:       func(string, ...interface{}) {},
+		},
+		releases: []*release{
+			{version: semver{Major: 1, Minor: 1}, tag: "v1.1.0"},
+		},
+		git: mockGit,
+	}
+
+	moved, err := p.moveAliases(&transaction{log: p.opts.Log})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"v1"}, moved)
+	mockGit.AssertExpectations(t)
+}
+
+func TestReleaseAliases(t *testing.T) {
+	errTest := errors.New("test error")
+
+	tests := []struct {
+		description string
+		pushErr     error
+	}{
+		{
+			description: "pushed",
+		}, {
+			description: "alias push fails",
+			pushErr:     errTest,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			assert := assert.New(t)
+
+			mockGit := &mockGit{}
+			mockGit.On("TagHead", "v1.1.0", mock.Anything).Return(nil)
+			mockGit.On("CreateArchive", "bar-1.1.0", "v1.1.0", "", mock.Anything, "./artifacts", mock.Anything).
+				Return(git.Archive{File: "./artifacts/bar-1.1.0"}, nil)
+			mockGit.On("Tags").Return([]string{"v1.0.0", "v1.1.0"}, nil)
+			mockGit.On("ResolveCommit", "v1.1.0").Return("abc", nil)
+			mockGit.On("MoveTag", "v1", "Latest release: v1.1.0", "abc").Return("", nil)
+			mockGit.On("PushTags", "token", []string{"v1.1.0"}).Return(nil)
+			mockGit.On("PushAliasTags", "token", []string{"v1"}).Return(tc.pushErr)
+
+			rel := &changelog.Release{
+				Version: "v1.1.0",
+				Body:    []string{"## [v1.1.0]", "- new body"},
+			}
+			p := &Project{
+				opts: ProjectOpts{
+					BasePath:    ".",
+					Token:       "token",
+					TagPrefix:   "v",
+					ArtifactDir: "artifacts",
+					SHASumFile:  "sha256sum.txt",
+					AliasTags:   []string{AliasMajor},
+					Log:         func(string, ...interface{}) {},
+				},
+				repoName:    "bar",
+				fs:          &afero.Afero{Fs: afero.NewMemMapFs()},
+				nextRelease: rel,
+				releases: []*release{
+					{
+						rel:      rel,
+						version:  semver{Major: 1, Minor: 1},
+						tag:      "v1.1.0",
+						artDir:   "artifacts",
+						bodyFile: ".release-body.md",
+					},
+				},
+				git: mockGit,
+			}
+
+			err := p.Release()
+			mockGit.AssertExpectations(t)
+			assert.Equal([]string{"v1"}, p.releases[0].aliases)
+
+			// The release tags are already upstream, so nothing is undone.
+			mockGit.AssertNotCalled(t, "DeleteTag", mock.Anything)
+			mockGit.AssertNotCalled(t, "RestoreTag", mock.Anything, mock.Anything)
+			if tc.pushErr != nil {
+				assert.ErrorIs(err, tc.pushErr)
+				return
+			}
+			assert.NoError(err)
+		})
+	}
+}
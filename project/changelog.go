@@ -0,0 +1,180 @@
+// SPDX-FileCopyrightText: 2021 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+package project
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var (
+	linkRefRE = regexp.MustCompile(`^\[([^\]]+)\]:\s*(\S+)`)
+)
+
+// section is a release section of the raw changelog file.  Line numbers
+// are indexes into the changelog lines.
+type section struct {
+	line        int
+	end         int
+	label       string
+	subsections []subsection
+	content     bool
+}
+
+// subsection is a '### Type' block inside of a release section.
+type subsection struct {
+	line  int
+	name  string
+	items []string
+}
+
+// linkRef is a markdown reference link such as '[1.2.3]: https://...'.
+type linkRef struct {
+	line  int
+	label string
+	url   string
+}
+
+// rawChangelog is the structure of the changelog file by line number, which
+// is needed for reporting problems and editing the file in place.
+type rawChangelog struct {
+	sections []section
+	links    []linkRef
+}
+
+// isUnreleased returns true if the label is the unreleased section.
+func isUnreleased(label string) bool {
+	return "unreleased" == strings.ToLower(label)
+}
+
+// sectionLabel returns the version label of a '## ' heading line.
+func sectionLabel(line string) string {
+	s := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(line), "##"))
+	if strings.HasPrefix(s, "[") {
+		if i := strings.Index(s, "]"); i > 0 {
+			return s[1:i]
+		}
+	}
+
+	if i := strings.IndexAny(s, " \t"); i > 0 {
+		return s[:i]
+	}
+	return s
+}
+
+func scanChangelog(lines []string) rawChangelog {
+	var raw rawChangelog
+	var cur *section
+
+	closeSection := func(i int) {
+		if cur != nil {
+			cur.end = i
+			raw.sections = append(raw.sections, *cur)
+			cur = nil
+		}
+	}
+
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+
+		if m := linkRefRE.FindStringSubmatch(trimmed); m != nil {
+			closeSection(i)
+			raw.links = append(raw.links, linkRef{
+				line:  i,
+				label: m[1],
+				url:   m[2],
+			})
+			continue
+		}
+
+		switch {
+		case strings.HasPrefix(trimmed, "## "):
+			closeSection(i)
+			cur = &section{
+				line:  i,
+				label: sectionLabel(trimmed),
+			}
+		case cur == nil:
+		case strings.HasPrefix(trimmed, "### "):
+			cur.subsections = append(cur.subsections, subsection{
+				line: i,
+				name: strings.TrimSpace(strings.TrimPrefix(trimmed, "###")),
+			})
+		case trimmed != "":
+			cur.content = true
+			if n := len(cur.subsections); n > 0 {
+				cur.subsections[n-1].items = append(cur.subsections[n-1].items, trimmed)
+			}
+		}
+	}
+	closeSection(len(lines))
+
+	return raw
+}
+
+// expectedLinks returns the reference links the changelog should end with,
+// in the order of the release sections.  Each release links to the
+// comparison with the previous release, the oldest release links to its tag
+// and the unreleased section links to the comparison with HEAD.  The links
+// use the existing tag of each release, which may lack the tag prefix, and
+// the canonical tag for releases that aren't tagged yet.
+func (p *Project) expectedLinks() ([]linkRef, error) {
+	var labels, tags []string
+	for i := range p.changelog.Releases {
+		rel := &p.changelog.Releases[i]
+		if isUnreleased(rel.Version) {
+			continue
+		}
+
+		tag, v, err := p.tagName(rel)
+		if err != nil {
+			return nil, err
+		}
+		name, err := p.findTag(tag, v)
+		if err != nil {
+			return nil, err
+		}
+		if name != "" {
+			tag = name
+		}
+		labels = append(labels, rel.Version)
+		tags = append(tags, tag)
+	}
+
+	base := "https://github.com/" + p.opts.Slug
+	var links []linkRef
+	for _, rel := range p.changelog.Releases {
+		if isUnreleased(rel.Version) && len(tags) > 0 {
+			links = append(links, linkRef{
+				label: rel.Version,
+				url:   base + "/compare/" + tags[0] + "...HEAD",
+			})
+		}
+	}
+
+	for i := range tags {
+		url := base + "/releases/tag/" + tags[i]
+		if i+1 < len(tags) {
+			url = base + "/compare/" + tags[i+1] + "..." + tags[i]
+		}
+		links = append(links, linkRef{
+			label: labels[i],
+			url:   url,
+		})
+	}
+
+	return links, nil
+}
+
+// writeChangelog replaces the changelog file with the lines.
+func (p *Project) writeChangelog(lines []string) error {
+	path := p.opts.BasePath + "/" + p.opts.ChangelogFile
+	err := p.fs.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0644)
+	if err != nil {
+		return fmt.Errorf("%w: unable to write to file '%s'", err, path)
+	}
+
+	p.changelogLines = lines
+	return nil
+}
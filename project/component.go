@@ -0,0 +1,153 @@
+// SPDX-FileCopyrightText: 2021 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+package project
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+	"time"
+
+	gh "github.com/sethvargo/go-githubactions"
+)
+
+var (
+	errComponentPath      = errors.New("the component path must be specified")
+	errComponentDuplicate = errors.New("the component name is used more than once")
+	errComponentUnknown   = errors.New("the component is unknown")
+)
+
+// Component is an independently released part of a repository with its own
+// changelog and tags, like a Go module or library in a sub directory.
+type Component struct {
+	// Name is used for the archives, artifact directory and outputs.  It
+	// defaults to the last element of the path.
+	Name string `json:"name"`
+
+	// Path is the directory of the component relative to the repository.
+	Path string `json:"path"`
+
+	// ChangelogFile is relative to the path and defaults to the project
+	// changelog file name.
+	ChangelogFile string `json:"changelog"`
+
+	// TagPrefix defaults to the path followed by the project tag prefix,
+	// which is the Go module convention: 'sub/dir/v1.2.3'.
+	TagPrefix string `json:"tag-prefix"`
+}
+
+// componentOutput is the per component information provided in the
+// components output.
+type componentOutput struct {
+	Releases         []releaseOutput `json:"releases"`
+	SuggestedVersion string          `json:"suggested-version,omitempty"`
+}
+
+// newComponent creates the project that handles a component.  It shares the
+// repository and settings of the parent project.
+func (p *Project) newComponent(c Component) (*Project, error) {
+	c.Path = strings.Trim(path.Clean(c.Path), "/")
+	if c.Path == "" || c.Path == "." {
+		return nil, errComponentPath
+	}
+	if c.Name == "" {
+		c.Name = path.Base(c.Path)
+	}
+	if c.ChangelogFile == "" {
+		c.ChangelogFile = path.Base(p.opts.ChangelogFile)
+	}
+	if c.TagPrefix == "" {
+		c.TagPrefix = c.Path + "/" + p.opts.TagPrefix
+	}
+
+	var nested []string
+	for _, other := range p.components {
+		if other.component.Name == c.Name {
+			return nil, fmt.Errorf("%w: '%s'", errComponentDuplicate, c.Name)
+		}
+		if isBelow(other.component.Path, c.Path) {
+			nested = append(nested, other.component.Path)
+		}
+		if isBelow(c.Path, other.component.Path) {
+			other.nested = append(other.nested, c.Path)
+		}
+	}
+
+	opts := p.opts
+	opts.Components = nil
+	opts.ChangelogFile = path.Join(c.Path, c.ChangelogFile)
+	opts.TagPrefix = c.TagPrefix
+	opts.ArtifactDir = p.opts.ArtifactDir + "/" + c.Name
+
+	return &Project{
+		opts:      opts,
+		dryRun:    p.dryRun,
+		org:       p.org,
+		repoName:  c.Name,
+		fs:        p.fs,
+		git:       p.git,
+		component: &c,
+		nested:    nested,
+	}, nil
+}
+
+// isBelow returns true if the path is inside the directory.
+func isBelow(file, dir string) bool {
+	return strings.HasPrefix(file, dir+"/")
+}
+
+// projects returns the component projects or the project itself if there are
+// no components.
+func (p *Project) projects() []*Project {
+	if len(p.components) > 0 {
+		return p.components
+	}
+	return []*Project{p}
+}
+
+// componentPath returns the path of the component relative to the repository
+// or an empty string for the whole repository.
+func (p *Project) componentPath() string {
+	if p.component == nil {
+		return ""
+	}
+	return p.component.Path
+}
+
+// findComponent returns the component project with the specified name.
+func (p *Project) findComponent(name string) (*Project, error) {
+	for _, c := range p.components {
+		if c.component.Name == name {
+			return c, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: '%s'", errComponentUnknown, name)
+}
+
+func (p *Project) outputComponents() error {
+	now := time.Now().Format("2006-01-02")
+
+	outputs := map[string]componentOutput{}
+	for _, c := range p.components {
+		out := componentOutput{
+			Releases: []releaseOutput{},
+		}
+		for _, r := range c.releases {
+			out.Releases = append(out.Releases, c.releaseOutput(r, now))
+		}
+		if c.suggested != nil {
+			out.SuggestedVersion = c.opts.TagPrefix + c.suggested.version.String()
+		}
+		outputs[c.component.Name] = out
+	}
+
+	buf, err := json.Marshal(outputs)
+	if err != nil {
+		return fmt.Errorf("%w: unable to encode the components output", err)
+	}
+
+	gh.SetOutput("components", string(buf))
+	return nil
+}
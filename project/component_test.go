@@ -0,0 +1,172 @@
+// SPDX-FileCopyrightText: 2021 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+package project
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/xmidt-org/release-builder-action/git"
+)
+
+func TestNewComponent(t *testing.T) {
+	tests := []struct {
+		description string
+		components  []Component
+		expected    []Component
+		nested      map[string][]string
+		expectedErr error
+	}{
+		{
+			description: "defaults",
+			components: []Component{
+				{Path: "sub/dir/"},
+			},
+			expected: []Component{
+				{Name: "dir", Path: "sub/dir", ChangelogFile: "CHANGELOG.md", TagPrefix: "sub/dir/v"},
+			},
+		},
+		{
+			description: "everything specified",
+			components: []Component{
+				{Name: "lib", Path: "c/lib", ChangelogFile: "NEWS.md", TagPrefix: "lib-"},
+			},
+			expected: []Component{
+				{Name: "lib", Path: "c/lib", ChangelogFile: "NEWS.md", TagPrefix: "lib-"},
+			},
+		},
+		{
+			description: "nested components",
+			components: []Component{
+				{Path: "lib/plugin"},
+				{Path: "lib"},
+				{Path: "library"},
+				{Path: "lib/plugin/extra"},
+			},
+			expected: []Component{
+				{Name: "plugin", Path: "lib/plugin", ChangelogFile: "CHANGELOG.md", TagPrefix: "lib/plugin/v"},
+				{Name: "lib", Path: "lib", ChangelogFile: "CHANGELOG.md", TagPrefix: "lib/v"},
+				{Name: "library", Path: "library", ChangelogFile: "CHANGELOG.md", TagPrefix: "library/v"},
+				{Name: "extra", Path: "lib/plugin/extra", ChangelogFile: "CHANGELOG.md", TagPrefix: "lib/plugin/extra/v"},
+			},
+			nested: map[string][]string{
+				"plugin": {"lib/plugin/extra"},
+				"lib":    {"lib/plugin", "lib/plugin/extra"},
+			},
+		},
+		{
+			description: "missing path",
+			components: []Component{
+				{Name: "lib"},
+			},
+			expectedErr: errComponentPath,
+		},
+		{
+			description: "duplicate name",
+			components: []Component{
+				{Path: "a/lib"},
+				{Path: "b/lib"},
+			},
+			expectedErr: errComponentDuplicate,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			assert := assert.New(t)
+
+			p, err := NewProject(ProjectOpts{
+				Slug:          "foo/bar",
+				BasePath:      "..",
+				TagPrefix:     "v",
+				ChangelogFile: "CHANGELOG.md",
+				ArtifactDir:   "artifacts",
+				SHASumFile:    "sha256sum.txt",
+				Components:    tc.components,
+			}, true)
+			if tc.expectedErr != nil {
+				assert.True(errors.Is(err, tc.expectedErr),
+					fmt.Errorf("error [%v] doesn't contain error [%v] in its err chain",
+						err, tc.expectedErr),
+				)
+				return
+			}
+			require.NoError(t, err)
+
+			var got []Component
+			for _, c := range p.components {
+				got = append(got, *c.component)
+				assert.Equal(tc.nested[c.component.Name], c.nested)
+				assert.Equal("artifacts/"+c.component.Name, c.opts.ArtifactDir)
+				assert.Equal(c.component.Path+"/"+c.component.ChangelogFile, c.opts.ChangelogFile)
+			}
+			assert.Equal(tc.expected, got)
+		})
+	}
+}
+
+func TestComponentRelease(t *testing.T) {
+	assert := assert.New(t)
+
+	mockGit := &mockGit{}
+	mockGit.On("IsTagPresent", "sub/lib/v1.1.0").Return(false, nil)
+	mockGit.On("Tags").Return([]string{"v2.0.0", "sub/lib/v1.0.0"}, nil)
+	mockGit.On("TagHead", "sub/lib/v1.1.0", mock.Anything).Return(nil)
+	mockGit.On("CreateArchive", "lib-1.1.0", "sub/lib/v1.1.0", "sub/lib", git.Format{Name: "zip"}, "./artifacts/lib", mock.Anything).Return(git.Archive{File: "./artifacts/lib/lib-1.1.0.zip"}, nil)
+	mockGit.On("CreateArchive", "lib-1.1.0", "sub/lib/v1.1.0", "sub/lib", git.Format{Name: "tar.gz"}, "./artifacts/lib", mock.Anything).Return(git.Archive{
+		File:     "./artifacts/lib/lib-1.1.0.tar.gz",
+		Excluded: []string{"tests/fixtures/"},
+	}, nil)
+	mockGit.On("PushTags", "token", []string{"sub/lib/v1.1.0"}).Return(nil)
+	mockGit.On("IsShallow").Return(false, nil)
+	mockGit.On("RemoteTags", "token").Return(map[string]git.TagRef{}, nil)
+
+	var logs []string
+	p := &Project{
+		opts: ProjectOpts{
+			Slug:          "foo/bar",
+			BasePath:      ".",
+			Token:         "token",
+			TagPrefix:     "v",
+			ChangelogFile: "CHANGELOG.md",
+			ArtifactDir:   "artifacts",
+			SHASumFile:    "sha256sum.txt",
+			Log: func(format string, v ...interface{}) {
+				logs = append(logs, fmt.Sprintf(format, v...))
+			},
+		},
+		repoName: "bar",
+		fs: &afero.Afero{
+			Fs: afero.NewMemMapFs(),
+		},
+		git: mockGit,
+	}
+	c, err := p.newComponent(Component{Path: "sub/lib"})
+	require.NoError(t, err)
+	p.components = append(p.components, c)
+
+	require.NoError(t, writeFile(p.fs, "sub/lib/CHANGELOG.md", `# Changelog
+
+## [Unreleased]
+
+## [v1.1.0]
+### Added
+- example
+`))
+
+	assert.NoError(p.ExamineProject())
+	assert.True(p.FoundNewRelease())
+	if assert.Len(c.releases, 1) {
+		assert.Equal("sub/lib/v1.1.0", c.releases[0].tag)
+		assert.Equal(".release-body-lib.md", c.releases[0].bodyFile)
+	}
+
+	assert.NoError(p.Release())
+	assert.Contains(logs, "Excluded by export-ignore: tests/fixtures/")
+	mockGit.AssertExpectations(t)
+}
@@ -0,0 +1,39 @@
+// SPDX-FileCopyrightText: 2021 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+package project
+
+import (
+	"github.com/pmezard/go-difflib/difflib"
+)
+
+const (
+	diffContext = 3
+)
+
+// unifiedDiff returns a unified diff of the two sets of lines or an empty
+// string if they are the same.  The lines don't hold the newlines, which
+// are added so every line of the diff is terminated.
+func unifiedDiff(name string, a, b []string) string {
+	diff, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        withNewlines(a),
+		B:        withNewlines(b),
+		FromFile: "a/" + name,
+		ToFile:   "b/" + name,
+		Context:  diffContext,
+	})
+	if err != nil {
+		// The diff is written to a strings.Builder, which never fails.
+		return ""
+	}
+
+	return diff
+}
+
+// withNewlines returns the lines with a newline added to each.
+func withNewlines(lines []string) []string {
+	out := make([]string, len(lines))
+	for i, line := range lines {
+		out[i] = line + "\n"
+	}
+	return out
+}
@@ -0,0 +1,73 @@
+// SPDX-FileCopyrightText: 2021 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+package project
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUnifiedDiff(t *testing.T) {
+	lines := strings.Split("1 2 3 4 5 6 7 8 9 10 11 12 13 14 15 16 17 18 19 20", " ")
+	replace := func(i int, s string) []string {
+		out := append([]string{}, lines...)
+		out[i] = s
+		return out
+	}
+
+	tests := []struct {
+		description string
+		a           []string
+		b           []string
+		expected    string
+	}{
+		{
+			description: "same",
+			a:           lines,
+			b:           lines,
+		}, {
+			description: "empty",
+		}, {
+			description: "from empty",
+			b:           []string{"1", "2"},
+			expected:    "--- a/f\n+++ b/f\n@@ -0,0 +1,2 @@\n+1\n+2\n",
+		}, {
+			description: "to empty",
+			a:           []string{"1", "2"},
+			expected:    "--- a/f\n+++ b/f\n@@ -1,2 +0,0 @@\n-1\n-2\n",
+		}, {
+			description: "hunk header",
+			a:           lines,
+			b:           replace(9, "ten"),
+			expected: "--- a/f\n+++ b/f\n@@ -7,7 +7,7 @@\n" +
+				" 7\n 8\n 9\n-10\n+ten\n 11\n 12\n 13\n",
+		}, {
+			description: "separate hunks",
+			a:           lines,
+			b:           append(replace(2, "three")[:17], append([]string{"eighteen"}, lines[18:]...)...),
+			expected: "--- a/f\n+++ b/f\n@@ -1,6 +1,6 @@\n" +
+				" 1\n 2\n-3\n+three\n 4\n 5\n 6\n" +
+				"@@ -15,6 +15,6 @@\n" +
+				" 15\n 16\n 17\n-18\n+eighteen\n 19\n 20\n",
+		}, {
+			description: "changes sharing context",
+			a:           lines,
+			b:           append(replace(4, "five")[:9], append([]string{"ten"}, lines[10:]...)...),
+			expected: "--- a/f\n+++ b/f\n@@ -2,12 +2,12 @@\n" +
+				" 2\n 3\n 4\n-5\n+five\n 6\n 7\n 8\n 9\n-10\n+ten\n 11\n 12\n 13\n",
+		}, {
+			description: "last line without a newline",
+			a:           []string{"1", "2"},
+			b:           []string{"1", "two"},
+			expected:    "--- a/f\n+++ b/f\n@@ -1,2 +1,2 @@\n 1\n-2\n+two\n",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			assert.Equal(t, tc.expected, unifiedDiff("f", tc.a, tc.b))
+		})
+	}
+}
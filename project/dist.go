@@ -0,0 +1,116 @@
+// SPDX-FileCopyrightText: 2021 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+package project
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+
+	"github.com/xmidt-org/release-builder-action/git"
+)
+
+var (
+	errDistOverlay = errors.New("the dist overlay must be a directory")
+)
+
+// Dist are the files that aren't in the repo added to the archives, like a
+// generated configure script or a VERSION file.  The repo isn't changed.
+type Dist struct {
+	// Overlay is the directory, relative to the base path, whose files are
+	// added to the archives at the same paths below the archive prefix.
+	Overlay string
+
+	// VersionFiles are the files generated in the archives that only hold
+	// the version, like VERSION or .tarball-version.
+	VersionFiles []string
+
+	// MetadataFile is the file generated in the archives that holds the
+	// version, tag and commit of the release.
+	MetadataFile string
+}
+
+// distFiles returns the files added to the archives of the release with the
+// tag and version.  The generated files replace overlay files with the same
+// name.
+func (p *Project) distFiles(tag string, v semver) ([]git.ArchiveFile, error) {
+	files := map[string]git.ArchiveFile{}
+
+	if p.opts.Dist.Overlay != "" {
+		if err := p.readOverlay(files); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, name := range p.opts.Dist.VersionFiles {
+		files[name] = git.ArchiveFile{
+			Name: name,
+			Data: []byte(v.String() + "\n"),
+		}
+	}
+
+	if name := p.opts.Dist.MetadataFile; name != "" {
+		commit, err := p.git.ResolveCommit(tag)
+		if err != nil {
+			return nil, err
+		}
+		files[name] = git.ArchiveFile{
+			Name: name,
+			Data: []byte(fmt.Sprintf("version=%s\ntag=%s\ncommit=%s\n", v, tag, commit)),
+		}
+	}
+
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	list := make([]git.ArchiveFile, len(names))
+	for i, name := range names {
+		list[i] = files[name]
+	}
+
+	return list, nil
+}
+
+// readOverlay adds every file below the overlay directory.  Only the
+// executable bit of the file mode is kept.
+func (p *Project) readOverlay(files map[string]git.ArchiveFile) error {
+	root := path.Join(p.opts.BasePath, p.opts.Dist.Overlay)
+
+	fi, err := p.fs.Stat(root)
+	if err != nil || !fi.IsDir() {
+		return fmt.Errorf("%w: '%s'", errDistOverlay, p.opts.Dist.Overlay)
+	}
+
+	return p.fs.Walk(root, func(file string, info os.FileInfo, err error) error {
+		if err != nil {
+			return fmt.Errorf("%w: unable to read '%s'", err, file)
+		}
+		if info.IsDir() {
+			return nil
+		}
+
+		rel, err := filepath.Rel(root, file)
+		if err != nil {
+			return err
+		}
+		name := filepath.ToSlash(rel)
+
+		data, err := p.fs.ReadFile(file)
+		if err != nil {
+			return fmt.Errorf("%w: unable to read '%s'", err, file)
+		}
+
+		files[name] = git.ArchiveFile{
+			Name:       name,
+			Data:       data,
+			Executable: info.Mode()&0111 != 0,
+		}
+		return nil
+	})
+}
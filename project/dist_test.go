@@ -0,0 +1,94 @@
+// SPDX-FileCopyrightText: 2021 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+package project
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xmidt-org/release-builder-action/git"
+)
+
+func TestDistFiles(t *testing.T) {
+	tests := []struct {
+		description string
+		dist        Dist
+		expected    []git.ArchiveFile
+		expectedErr error
+	}{
+		{
+			description: "nothing added",
+			expected:    []git.ArchiveFile{},
+		}, {
+			description: "overlay",
+			dist:        Dist{Overlay: "dist"},
+			expected: []git.ArchiveFile{
+				{Name: "VERSION", Data: []byte("0.0.0\n")},
+				{Name: "build-aux/config.guess", Data: []byte("guess\n"), Executable: true},
+				{Name: "configure", Data: []byte("#!/bin/sh\n"), Executable: true},
+			},
+		}, {
+			description: "generated files replace the overlay",
+			dist: Dist{
+				Overlay:      "dist",
+				VersionFiles: []string{"VERSION", ".tarball-version"},
+				MetadataFile: "release.txt",
+			},
+			expected: []git.ArchiveFile{
+				{Name: ".tarball-version", Data: []byte("1.2.3\n")},
+				{Name: "VERSION", Data: []byte("1.2.3\n")},
+				{Name: "build-aux/config.guess", Data: []byte("guess\n"), Executable: true},
+				{Name: "configure", Data: []byte("#!/bin/sh\n"), Executable: true},
+				{Name: "release.txt", Data: []byte("version=1.2.3\ntag=v1.2.3\ncommit=abc123\n")},
+			},
+		}, {
+			description: "missing overlay",
+			dist:        Dist{Overlay: "missing"},
+			expectedErr: errDistOverlay,
+		}, {
+			description: "overlay is a file",
+			dist:        Dist{Overlay: "dist/configure"},
+			expectedErr: errDistOverlay,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			assert := assert.New(t)
+
+			fs := &afero.Afero{Fs: afero.NewMemMapFs()}
+			require.NoError(t, writeFile(fs, "dist/VERSION", "0.0.0\n"))
+			require.NoError(t, writeFile(fs, "dist/configure", "#!/bin/sh\n"))
+			require.NoError(t, writeFile(fs, "dist/build-aux/config.guess", "guess\n"))
+			require.NoError(t, fs.Chmod("dist/configure", 0755))
+			require.NoError(t, fs.Chmod("dist/build-aux/config.guess", 0755))
+
+			mockGit := &mockGit{}
+			mockGit.On("ResolveCommit", "v1.2.3").Return("abc123", nil).Maybe()
+
+			p := &Project{
+				opts: ProjectOpts{
+					BasePath: ".",
+					Dist:     tc.dist,
+				},
+				fs:  fs,
+				git: mockGit,
+			}
+
+			got, err := p.distFiles("v1.2.3", semver{Major: 1, Minor: 2, Patch: 3})
+			if tc.expectedErr != nil {
+				assert.True(errors.Is(err, tc.expectedErr),
+					fmt.Errorf("error [%v] doesn't contain error [%v] in its err chain",
+						err, tc.expectedErr),
+				)
+				return
+			}
+			assert.NoError(err)
+			assert.Equal(tc.expected, got)
+		})
+	}
+}
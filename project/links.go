@@ -0,0 +1,91 @@
+// SPDX-FileCopyrightText: 2021 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+package project
+
+import (
+	"strings"
+)
+
+// FixLinks rebuilds the reference links at the bottom of the changelog from
+// the releases and writes the file back in place.  During a dry run the
+// changes are only logged as a diff.
+func (p *Project) FixLinks() error {
+	for _, c := range p.projects() {
+		if err := c.fixLinks(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (p *Project) fixLinks() error {
+	p.opts.Log("Processing the %s file.", p.opts.ChangelogFile)
+	if err := p.processChangelog(); err != nil {
+		return err
+	}
+
+	lines, err := p.fixedLinks(p.changelogLines)
+	if err != nil {
+		return err
+	}
+
+	diff := unifiedDiff(p.opts.ChangelogFile, p.changelogLines, lines)
+	if diff == "" {
+		p.opts.Log("The changelog links are correct.")
+		return nil
+	}
+
+	p.opts.Log("%s", diff)
+
+	if p.dryRun {
+		p.opts.Log("This is a dry run, do not alter the changelog.")
+		return nil
+	}
+
+	p.opts.Log("Updating the %s file.", p.opts.ChangelogFile)
+	return p.writeChangelog(lines)
+}
+
+// fixedLinks returns the changelog lines with every release reference link
+// removed and the expected links appended.  Reference links that are not for
+// releases are left alone.
+func (p *Project) fixedLinks(lines []string) ([]string, error) {
+	raw := scanChangelog(lines)
+
+	headings := map[string]bool{}
+	for _, s := range raw.sections {
+		headings[strings.ToLower(s.label)] = true
+	}
+
+	remove := map[int]bool{}
+	for _, l := range raw.links {
+		_, err := p.parseVersion(l.label)
+		if headings[strings.ToLower(l.label)] || isUnreleased(l.label) || err == nil {
+			remove[l.line] = true
+		}
+	}
+
+	fixed := make([]string, 0, len(lines))
+	for i, line := range lines {
+		if !remove[i] {
+			fixed = append(fixed, line)
+		}
+	}
+	for len(fixed) > 0 && strings.TrimSpace(fixed[len(fixed)-1]) == "" {
+		fixed = fixed[:len(fixed)-1]
+	}
+
+	expected, err := p.expectedLinks()
+	if err != nil {
+		return nil, err
+	}
+	if len(expected) > 0 {
+		fixed = append(fixed, "")
+	}
+	for _, l := range expected {
+		fixed = append(fixed, "["+l.label+"]: "+l.url)
+	}
+
+	return fixed, nil
+}
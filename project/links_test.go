@@ -0,0 +1,145 @@
+// SPDX-FileCopyrightText: 2021 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+package project
+
+import (
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	driftedChangelog = `# Changelog
+
+## [Unreleased]
+
+## [v1.1.0]
+### Added
+- example
+
+## [1.0.0]
+### Fixed
+- example
+
+[Unreleased]: https://github.com/foo/bar/compare/v1.0.0...HEAD
+[1.0.0]: https://github.com/foo/bar/compare/v1.0.0...v1.1.0
+[1.0.0]: https://github.com/foo/bar/releases/tag/v1.0.0
+[0.9.0]: https://github.com/foo/bar/releases/tag/v0.9.0
+[docs]: https://example.com/docs
+
+`
+	bareTagChangelog = `# Changelog
+
+## [Unreleased]
+
+## [v1.1.0]
+### Added
+- example
+
+## [1.0.0]
+### Fixed
+- example
+`
+)
+
+func TestFixLinks(t *testing.T) {
+	tests := []struct {
+		description string
+		contents    string
+		tags        []string
+		dryrun      bool
+		expected    string
+	}{
+		{
+			description: "already correct",
+			contents:    cleanChangelog,
+			expected:    cleanChangelog,
+		},
+		{
+			description: "links repaired",
+			contents:    driftedChangelog,
+			expected: `# Changelog
+
+## [Unreleased]
+
+## [v1.1.0]
+### Added
+- example
+
+## [1.0.0]
+### Fixed
+- example
+
+[docs]: https://example.com/docs
+
+[Unreleased]: https://github.com/foo/bar/compare/v1.1.0...HEAD
+[v1.1.0]: https://github.com/foo/bar/compare/v1.0.0...v1.1.0
+[1.0.0]: https://github.com/foo/bar/releases/tag/v1.0.0
+`,
+		},
+		{
+			description: "older release tagged without the prefix",
+			contents:    bareTagChangelog,
+			tags:        []string{"v1.1.0", "1.0.0"},
+			expected: `# Changelog
+
+## [Unreleased]
+
+## [v1.1.0]
+### Added
+- example
+
+## [1.0.0]
+### Fixed
+- example
+
+[Unreleased]: https://github.com/foo/bar/compare/v1.1.0...HEAD
+[v1.1.0]: https://github.com/foo/bar/compare/1.0.0...v1.1.0
+[1.0.0]: https://github.com/foo/bar/releases/tag/1.0.0
+`,
+		},
+		{
+			description: "dry run leaves the file alone",
+			contents:    driftedChangelog,
+			dryrun:      true,
+			expected:    driftedChangelog,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			assert := assert.New(t)
+
+			mockGit := &mockGit{}
+			for _, tag := range tc.tags {
+				mockGit.On("IsTagPresent", tag).Return(true, nil)
+			}
+			mockGit.On("IsTagPresent", mock.Anything).Return(false, nil)
+
+			p := &Project{
+				opts: ProjectOpts{
+					Slug:          "foo/bar",
+					ChangelogFile: "CHANGELOG.md",
+					BasePath:      ".",
+					TagPrefix:     "v",
+					Log:           func(string, ...interface{}) {},
+				},
+				dryRun: tc.dryrun,
+				fs: &afero.Afero{
+					Fs: afero.NewMemMapFs(),
+				},
+				git: mockGit,
+			}
+			require.NoError(t, writeFile(p.fs, "CHANGELOG.md", tc.contents))
+
+			assert.NoError(p.FixLinks())
+
+			got, err := p.fs.ReadFile("./CHANGELOG.md")
+			require.NoError(t, err)
+			assert.Equal(tc.expected, string(got))
+		})
+	}
+}
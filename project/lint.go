@@ -0,0 +1,237 @@
+// SPDX-FileCopyrightText: 2021 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+package project
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+var (
+	sectionTypes = []string{"Added", "Changed", "Deprecated", "Removed", "Fixed", "Security"}
+)
+
+// Problem is an issue found with the changelog file.
+type Problem struct {
+	File    string
+	Line    int
+	Message string
+}
+
+func (p Problem) String() string {
+	return fmt.Sprintf("%s:%d: %s", p.File, p.Line, p.Message)
+}
+
+// Lint examines the changelog file structure and returns the problems found
+// without altering the repo.
+func (p *Project) Lint() ([]Problem, error) {
+	var problems []Problem
+	for _, c := range p.projects() {
+		found, err := c.lint()
+		if err != nil {
+			return nil, err
+		}
+		problems = append(problems, found...)
+	}
+
+	return problems, nil
+}
+
+func (p *Project) lint() ([]Problem, error) {
+	p.opts.Log("Linting the %s file.", p.opts.ChangelogFile)
+	buf, path, err := p.readChangelog()
+	if err != nil {
+		return nil, err
+	}
+
+	// The versions are linted instead of validated so each problem is
+	// reported with its line.
+	if err = p.loadChangelog(buf, path); err != nil {
+		return nil, err
+	}
+
+	return p.lintChangelog()
+}
+
+func (p *Project) lintChangelog() ([]Problem, error) {
+	var problems []Problem
+	add := func(line int, format string, v ...interface{}) {
+		problems = append(problems, Problem{
+			File:    p.opts.ChangelogFile,
+			Line:    line + 1,
+			Message: fmt.Sprintf(format, v...),
+		})
+	}
+
+	raw := scanChangelog(p.changelogLines)
+
+	headings := map[string]int{}
+	foundUnreleased := false
+	for _, s := range raw.sections {
+		headings[strings.ToLower(s.label)] = s.line
+
+		if isUnreleased(s.label) {
+			foundUnreleased = true
+		} else if !s.content {
+			add(s.line, "the release section [%s] is empty", s.label)
+		}
+
+		for _, sub := range s.subsections {
+			if !isSectionType(sub.name) {
+				add(sub.line, "unknown section type '%s', expected one of: %s",
+					sub.name, strings.Join(sectionTypes, ", "))
+			}
+		}
+	}
+
+	// The bumps, the suggestion and the expected links all need valid and
+	// ordered versions.
+	valid := p.lintVersions(raw, add)
+	if valid {
+		if err := p.suggestNext(); err != nil {
+			return nil, err
+		}
+
+		if err := p.lintBumps(raw, add); err != nil {
+			return nil, err
+		}
+	}
+
+	if !foundUnreleased {
+		line := 0
+		if len(raw.sections) > 0 {
+			line = raw.sections[0].line
+		}
+		add(line, "the [Unreleased] section is missing")
+	}
+
+	links := map[string]linkRef{}
+	for _, l := range raw.links {
+		label := strings.ToLower(l.label)
+		if _, found := links[label]; found {
+			add(l.line, "the link [%s] is defined more than once", l.label)
+			continue
+		}
+		links[label] = l
+
+		if _, found := headings[label]; found {
+			continue
+		}
+		if _, err := p.parseVersion(l.label); err == nil || isUnreleased(l.label) {
+			add(l.line, "the link [%s] does not match a release in the changelog", l.label)
+		}
+	}
+
+	var expected []linkRef
+	if valid {
+		var err error
+		if expected, err = p.expectedLinks(); err != nil {
+			return nil, err
+		}
+	}
+	for _, want := range expected {
+		got, found := links[strings.ToLower(want.label)]
+		if !found {
+			add(headings[strings.ToLower(want.label)], "the link for [%s] is missing, expected: %s", want.label, want.url)
+			continue
+		}
+		if got.url != want.url {
+			add(got.line, "the link for [%s] is '%s', expected: %s", want.label, got.url, want.url)
+		}
+	}
+
+	sort.SliceStable(problems, func(i, j int) bool {
+		return problems[i].Line < problems[j].Line
+	})
+
+	return problems, nil
+}
+
+// lintVersions reports the release sections whose version isn't a semantic
+// version, is listed more than once or isn't older than the release listed
+// before it.  It returns false if any were found.
+func (p *Project) lintVersions(raw rawChangelog, add func(int, string, ...interface{})) bool {
+	valid := true
+	var prev *semver
+	var prevLabel string
+	seen := map[string]string{}
+
+	for _, s := range raw.sections {
+		if isUnreleased(s.label) {
+			continue
+		}
+
+		v, err := p.parseVersion(s.label)
+		if err != nil {
+			add(s.line, "the release [%s] is not a valid semantic version", s.label)
+			valid = false
+			continue
+		}
+
+		// Build metadata doesn't count when comparing versions.
+		key := v
+		key.Build = nil
+		if dup, found := seen[key.String()]; found {
+			add(s.line, "the release [%s] is the same version as [%s]", s.label, dup)
+			valid = false
+			continue
+		}
+		seen[key.String()] = s.label
+
+		if prev != nil && prev.Compare(v) <= 0 {
+			add(s.line, "the release [%s] is listed after the older release [%s]", s.label, prevLabel)
+			valid = false
+		}
+		prev = &v
+		prevLabel = s.label
+	}
+
+	return valid
+}
+
+// lintBumps reports releases whose version is a smaller bump from the
+// previous full release than the changes in the release need.
+func (p *Project) lintBumps(raw rawChangelog, add func(int, string, ...interface{})) error {
+	var sections []section
+	var versions []semver
+	for _, s := range raw.sections {
+		if isUnreleased(s.label) {
+			continue
+		}
+
+		v, err := p.parseVersion(s.label)
+		if err != nil {
+			return err
+		}
+		sections = append(sections, s)
+		versions = append(versions, v)
+	}
+
+	for i := range sections {
+		for j := i + 1; j < len(sections); j++ {
+			if versions[j].IsPrerelease() {
+				continue
+			}
+
+			need := requiredBump(sections[i], versions[j], p.opts.InitialDevelopment)
+			made := bumpBetween(versions[j], versions[i])
+			if made < need {
+				add(sections[i].line, "the release [%s] is a %s release but the changes need a %s release",
+					sections[i].label, bumpNames[made], bumpNames[need])
+			}
+			break
+		}
+	}
+
+	return nil
+}
+
+func isSectionType(name string) bool {
+	for _, t := range sectionTypes {
+		if t == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,187 @@
+// SPDX-FileCopyrightText: 2021 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+package project
+
+import (
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	cleanChangelog = `# Changelog
+
+## [Unreleased]
+
+## [v1.1.0]
+### Added
+- example
+
+## [1.0.0]
+### Fixed
+- example
+
+[Unreleased]: https://github.com/foo/bar/compare/v1.1.0...HEAD
+[v1.1.0]: https://github.com/foo/bar/compare/v1.0.0...v1.1.0
+[1.0.0]: https://github.com/foo/bar/releases/tag/v1.0.0
+`
+	underBumpChangelog = `# Changelog
+
+## [Unreleased]
+
+## [v1.0.1]
+### Removed
+- old thing
+
+## [1.0.0]
+### Fixed
+- example
+
+[Unreleased]: https://github.com/foo/bar/compare/v1.0.1...HEAD
+[v1.0.1]: https://github.com/foo/bar/compare/v1.0.0...v1.0.1
+[1.0.0]: https://github.com/foo/bar/releases/tag/v1.0.0
+`
+	zeroMajorChangelog = `# Changelog
+
+## [Unreleased]
+
+## [v0.2.0]
+### Removed
+- old thing
+
+## [0.1.0]
+### Fixed
+- example
+
+[Unreleased]: https://github.com/foo/bar/compare/v0.2.0...HEAD
+[v0.2.0]: https://github.com/foo/bar/compare/v0.1.0...v0.2.0
+[0.1.0]: https://github.com/foo/bar/releases/tag/v0.1.0
+`
+	brokenChangelog = `# Changelog
+
+## [v1.2.0]
+
+## [v1.1.0]
+### Improved
+- example
+
+## [1.0.0]
+### Fixed
+- example
+
+[v1.2.0]: https://github.com/foo/bar/compare/v1.1.0...v1.2.0
+[v1.1.0]: https://github.com/foo/bar/compare/v1.0.0...v1.2.0
+[v1.1.0]: https://github.com/foo/bar/compare/v1.0.0...v1.1.0
+[0.9.0]: https://github.com/foo/bar/releases/tag/v0.9.0
+`
+	badVersionsChangelog = `# Changelog
+
+## [Unreleased]
+
+## [v1.1.0]
+### Added
+- example
+
+## [next]
+### Fixed
+- example
+
+## [1.1.0]
+### Fixed
+- example
+
+## [v1.2.0]
+### Fixed
+- example
+
+[Unreleased]: https://github.com/foo/bar/compare/v1.1.0...HEAD
+[v1.1.0]: https://github.com/foo/bar/compare/v1.0.0...v1.1.0
+`
+)
+
+func TestLint(t *testing.T) {
+	tests := []struct {
+		description string
+		contents    string
+		initialDev  bool
+		expected    []Problem
+	}{
+		{
+			description: "no problems",
+			contents:    cleanChangelog,
+		},
+		{
+			description: "under bumped release",
+			contents:    underBumpChangelog,
+			expected: []Problem{
+				{File: "CHANGELOG.md", Line: 5, Message: "the release [v1.0.1] is a patch release but the changes need a major release"},
+			},
+		},
+		{
+			description: "under bumped release before 1.0.0",
+			contents:    zeroMajorChangelog,
+			expected: []Problem{
+				{File: "CHANGELOG.md", Line: 5, Message: "the release [v0.2.0] is a minor release but the changes need a major release"},
+			},
+		},
+		{
+			description: "initial development",
+			contents:    zeroMajorChangelog,
+			initialDev:  true,
+		},
+		{
+			description: "many problems",
+			contents:    brokenChangelog,
+			expected: []Problem{
+				{File: "CHANGELOG.md", Line: 3, Message: "the release section [v1.2.0] is empty"},
+				{File: "CHANGELOG.md", Line: 3, Message: "the [Unreleased] section is missing"},
+				{File: "CHANGELOG.md", Line: 6, Message: "unknown section type 'Improved', expected one of: Added, Changed, Deprecated, Removed, Fixed, Security"},
+				{File: "CHANGELOG.md", Line: 9, Message: "the link for [1.0.0] is missing, expected: https://github.com/foo/bar/releases/tag/v1.0.0"},
+				{File: "CHANGELOG.md", Line: 14, Message: "the link for [v1.1.0] is 'https://github.com/foo/bar/compare/v1.0.0...v1.2.0', expected: https://github.com/foo/bar/compare/v1.0.0...v1.1.0"},
+				{File: "CHANGELOG.md", Line: 15, Message: "the link [v1.1.0] is defined more than once"},
+				{File: "CHANGELOG.md", Line: 16, Message: "the link [0.9.0] does not match a release in the changelog"},
+			},
+		},
+		{
+			description: "version problems",
+			contents:    badVersionsChangelog,
+			expected: []Problem{
+				{File: "CHANGELOG.md", Line: 9, Message: "the release [next] is not a valid semantic version"},
+				{File: "CHANGELOG.md", Line: 13, Message: "the release [1.1.0] is the same version as [v1.1.0]"},
+				{File: "CHANGELOG.md", Line: 17, Message: "the release [v1.2.0] is listed after the older release [v1.1.0]"},
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			assert := assert.New(t)
+
+			mockGit := &mockGit{}
+			mockGit.On("IsTagPresent", mock.Anything).Return(false, nil)
+
+			p := &Project{
+				opts: ProjectOpts{
+					Slug:               "foo/bar",
+					ChangelogFile:      "CHANGELOG.md",
+					BasePath:           ".",
+					TagPrefix:          "v",
+					InitialDevelopment: tc.initialDev,
+					Log:                func(string, ...interface{}) {},
+				},
+				fs: &afero.Afero{
+					Fs: afero.NewMemMapFs(),
+				},
+				git: mockGit,
+			}
+			require.NoError(t, writeFile(p.fs, "CHANGELOG.md", tc.contents))
+
+			problems, err := p.Lint()
+			assert.NoError(err)
+			assert.Equal(tc.expected, problems)
+		})
+	}
+}
@@ -3,16 +3,55 @@
 package project
 
 import (
+	"errors"
 	"fmt"
+	"path"
+)
+
+const (
+	MesonCheckStrict = "strict"
+	MesonCheckWarn   = "warn"
+	MesonCheckOff    = "off"
+)
+
+var (
+	errMesonCheckUnknown = errors.New("the meson version check must be strict, warn or off")
+	errMesonArchive      = errors.New("the meson archive must be one of the archive formats")
 )
 
 type Meson struct {
 	Provides string
+
+	// VersionCheck is how a meson.build version that doesn't match the
+	// release is handled: strict fails, warn logs and off skips the check.
+	VersionCheck string
+
+	// Archive is the archive format the wrap file references.  By default
+	// the first tar archive is used, or the first archive if there is no
+	// tar archive.
+	Archive string
+}
+
+// mesonArchive returns the archive format the wrap file references.
+func (p *Project) mesonArchive() string {
+	if p.opts.Meson.Archive != "" {
+		return p.opts.Meson.Archive
+	}
+
+	formats := p.archiveFormats()
+	for _, f := range formats {
+		if f.IsTar() {
+			return f.Name
+		}
+	}
+	return formats[0].Name
 }
 
-func (p *Project) generateMesonWrapper(path, tgzFile string) error {
+// generateMesonWrapper writes the wrap file for the archive files, which are
+// keyed by format.
+func (p *Project) generateMesonWrapper(r *release, dir string, archives map[string]string) error {
 
-	found, err := p.fs.Exists("meson.build")
+	found, err := p.fs.Exists(path.Join(p.opts.BasePath, p.componentPath(), "meson.build"))
 	if err != nil {
 		return err
 	}
@@ -27,9 +66,10 @@ func (p *Project) generateMesonWrapper(path, tgzFile string) error {
 	}
 
 	p.opts.Log("Generating the meson wrapper file.")
-	slug := p.getReleaseSlug()
+	slug := p.getReleaseSlug(r.rel)
 
-	sha, err := sha(p.fs, tgzFile)
+	format := p.mesonArchive()
+	sha, err := sha(p.fs, archives[format])
 	if err != nil {
 		return err
 	}
@@ -37,18 +77,18 @@ func (p *Project) generateMesonWrapper(path, tgzFile string) error {
 	line := fmt.Sprintf(
 		"[wrap-file]\n"+
 			"directory = %s\n\n"+
-			"source_filename = %s.tar.gz\n"+
-			"source_url = https://github.com/%s/releases/download/%s/%s.tar.gz\n"+
+			"source_filename = %s.%s\n"+
+			"source_url = https://github.com/%s/releases/download/%s/%s.%s\n"+
 			"source_hash = %x\n\n"+
 			"[meson_provides]\n"+
 			"lib%s = lib%s_dep\n",
 		slug,
-		slug,
-		p.opts.Slug, p.nextRelease.Version, slug,
+		slug, format,
+		p.opts.Slug, r.tag, slug, format,
 		sha,
 		provides, provides)
 
-	file := path + "/" + provides + ".wrap"
+	file := dir + "/" + provides + ".wrap"
 	f, err := p.fs.Create(file)
 	if err != nil {
 		return fmt.Errorf("%w: unable to create file '%s'", err, file)
@@ -63,9 +103,72 @@ func (p *Project) generateMesonWrapper(path, tgzFile string) error {
 	return nil
 }
 
+// examineMesonProject compares the version in the project() call of the
+// meson.build file with the newest release.
 func (p *Project) examineMesonProject() error {
-	// TODO: To do this right we'd examine the meson file directly and validate
-	// the version number matches.  However, to do that with the tool as it stands
-	// today requires installing all the dependencies.
+	check := p.opts.Meson.VersionCheck
+	switch check {
+	case MesonCheckOff:
+		return nil
+	case "":
+		check = MesonCheckStrict
+	case MesonCheckStrict, MesonCheckWarn:
+	default:
+		return fmt.Errorf("%w: '%s'", errMesonCheckUnknown, check)
+	}
+
+	// The file is read from the commit being tagged, which may not be the
+	// working tree.
+	r := p.releases[0]
+	file := path.Join(p.componentPath(), "meson.build")
+	buf, found, err := p.readReleaseFile(r, file)
+	if err != nil {
+		return err
+	}
+	if !found {
+		return nil
+	}
+
+	mp, err := parseMesonProject(string(buf))
+	if err != nil {
+		return fmt.Errorf("%w: '%s'", err, file)
+	}
+
+	p.opts.Log("Found the meson project '%s' version '%s' requiring meson '%s'.",
+		mp.Name.Value, mp.Version.Value, mp.MesonVersion.Value)
+
+	switch {
+	case mp.Version.Line == 0:
+		p.opts.Log("The meson project has no version, there is nothing to check.")
+		return nil
+	case !mp.Version.Literal:
+		// The version may be read from a file or computed, so it can't be
+		// checked without running meson.
+		p.opts.Log("Warning: %s:%d has version %s, which isn't a string and can't be checked against '%s'.",
+			file, mp.Version.Line, mp.Version.Value, r.version)
+		return nil
+	}
+
+	err = mesonVersionMatches(mp.Version, r.version)
+	if err == nil {
+		return nil
+	}
+	err = fmt.Errorf("%w: %s:%d has version '%s' but the release is '%s'",
+		err, file, mp.Version.Line, mp.Version.Value, r.version)
+
+	if check == MesonCheckWarn {
+		p.opts.Log("Warning: %s", err)
+		return nil
+	}
+
+	return err
+}
+
+func mesonVersionMatches(mv mesonValue, v semver) error {
+	found, err := parseSemver(mv.Value, "")
+	if err != nil || found.Compare(v) != 0 {
+		return errVersionMismatch
+	}
+
 	return nil
 }
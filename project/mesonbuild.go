@@ -0,0 +1,249 @@
+// SPDX-FileCopyrightText: 2021 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+package project
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	errMesonParse = errors.New("unable to parse the meson.build file")
+)
+
+const (
+	mesonIdent = iota
+	mesonString
+	mesonNumber
+	mesonPunct
+)
+
+// mesonToken is a single token of a meson.build file.  Only enough of the
+// meson language is understood to find the project() call arguments.
+type mesonToken struct {
+	kind   int
+	text   string
+	line   int
+	format bool
+}
+
+// mesonValue is a value found in the project() call.  Literal is false when
+// the value is something other than a plain string, like a function call.
+// Line is 0 when the value isn't present.
+type mesonValue struct {
+	Value   string
+	Line    int
+	Literal bool
+}
+
+// mesonProject is the information found in the project() call of a
+// meson.build file.
+type mesonProject struct {
+	Name         mesonValue
+	Version      mesonValue
+	MesonVersion mesonValue
+}
+
+func tokenizeMeson(src string) ([]mesonToken, error) {
+	var tokens []mesonToken
+	line := 1
+
+	isIdentStart := func(c byte) bool {
+		return c == '_' || ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z')
+	}
+	isDigit := func(c byte) bool {
+		return '0' <= c && c <= '9'
+	}
+
+	for i := 0; i < len(src); {
+		c := src[i]
+		switch {
+		case c == '\n':
+			line++
+			i++
+		case c == ' ' || c == '\t' || c == '\r' || c == '\\':
+			i++
+		case c == '#':
+			for i < len(src) && src[i] != '\n' {
+				i++
+			}
+		case c == '\'':
+			start := line
+			s, n, lines, err := scanMesonString(src[i:])
+			if err != nil {
+				return nil, fmt.Errorf("%w: line %d %s", errMesonParse, line, err)
+			}
+			format := false
+			if l := len(tokens); l > 0 && tokens[l-1].kind == mesonIdent && tokens[l-1].text == "f" &&
+				i > 0 && src[i-1] == 'f' {
+				tokens = tokens[:l-1]
+				format = true
+			}
+			tokens = append(tokens, mesonToken{kind: mesonString, text: s, line: start, format: format})
+			line += lines
+			i += n
+		case isIdentStart(c):
+			j := i + 1
+			for j < len(src) && (isIdentStart(src[j]) || isDigit(src[j])) {
+				j++
+			}
+			tokens = append(tokens, mesonToken{kind: mesonIdent, text: src[i:j], line: line})
+			i = j
+		case isDigit(c):
+			j := i + 1
+			for j < len(src) && (isIdentStart(src[j]) || isDigit(src[j])) {
+				j++
+			}
+			tokens = append(tokens, mesonToken{kind: mesonNumber, text: src[i:j], line: line})
+			i = j
+		default:
+			tokens = append(tokens, mesonToken{kind: mesonPunct, text: string(c), line: line})
+			i++
+		}
+	}
+
+	return tokens, nil
+}
+
+// scanMesonString scans a single or triple quoted string at the start of src
+// and returns the value, the number of bytes and the number of newlines used.
+func scanMesonString(src string) (string, int, int, error) {
+	if strings.HasPrefix(src, "'''") {
+		end := strings.Index(src[3:], "'''")
+		if end < 0 {
+			return "", 0, 0, errors.New("unterminated string")
+		}
+		s := src[3 : 3+end]
+		return s, end + 6, strings.Count(s, "\n"), nil
+	}
+
+	var sb strings.Builder
+	for i := 1; i < len(src); i++ {
+		switch src[i] {
+		case '\'':
+			return sb.String(), i + 1, 0, nil
+		case '\n':
+			return "", 0, 0, errors.New("unterminated string")
+		case '\\':
+			i++
+			if i >= len(src) {
+				return "", 0, 0, errors.New("unterminated string")
+			}
+			switch src[i] {
+			case 'n':
+				sb.WriteByte('\n')
+			case 't':
+				sb.WriteByte('\t')
+			default:
+				sb.WriteByte(src[i])
+			}
+		default:
+			sb.WriteByte(src[i])
+		}
+	}
+
+	return "", 0, 0, errors.New("unterminated string")
+}
+
+// parseMesonProject finds the project() call in the meson.build contents and
+// returns the project name, version and meson_version arguments.
+func parseMesonProject(src string) (*mesonProject, error) {
+	tokens, err := tokenizeMeson(src)
+	if err != nil {
+		return nil, err
+	}
+
+	start := -1
+	for i := 0; i+1 < len(tokens); i++ {
+		if tokens[i].kind == mesonIdent && tokens[i].text == "project" &&
+			tokens[i+1].kind == mesonPunct && tokens[i+1].text == "(" {
+			start = i + 2
+			break
+		}
+	}
+	if start < 0 {
+		return nil, fmt.Errorf("%w: the project() call is missing", errMesonParse)
+	}
+
+	// Split the arguments at the top level commas.
+	var args [][]mesonToken
+	var arg []mesonToken
+	depth := 0
+	done := false
+	for i := start; i < len(tokens) && !done; i++ {
+		t := tokens[i]
+		if t.kind == mesonPunct {
+			switch t.text {
+			case "(", "[", "{":
+				depth++
+			case ")", "]", "}":
+				if depth == 0 {
+					done = true
+					continue
+				}
+				depth--
+			case ",":
+				if depth == 0 {
+					args = append(args, arg)
+					arg = nil
+					continue
+				}
+			}
+		}
+		arg = append(arg, t)
+	}
+	if !done {
+		return nil, fmt.Errorf("%w: the project() call is not closed", errMesonParse)
+	}
+	if len(arg) > 0 {
+		args = append(args, arg)
+	}
+
+	var mp mesonProject
+	for i, a := range args {
+		if len(a) >= 2 && a[0].kind == mesonIdent && a[1].kind == mesonPunct && a[1].text == ":" {
+			v := mesonArgValue(a[2:])
+			switch a[0].text {
+			case "version":
+				mp.Version = v
+			case "meson_version":
+				mp.MesonVersion = v
+			}
+			continue
+		}
+
+		if i == 0 {
+			mp.Name = mesonArgValue(a)
+		}
+	}
+
+	return &mp, nil
+}
+
+func mesonArgValue(tokens []mesonToken) mesonValue {
+	if len(tokens) == 0 {
+		return mesonValue{}
+	}
+
+	v := mesonValue{
+		Line: tokens[0].line,
+	}
+	if len(tokens) == 1 && tokens[0].kind == mesonString && !tokens[0].format {
+		v.Value = tokens[0].text
+		v.Literal = true
+		return v
+	}
+
+	texts := make([]string, 0, len(tokens))
+	for _, t := range tokens {
+		if t.kind == mesonString {
+			texts = append(texts, "'"+t.text+"'")
+			continue
+		}
+		texts = append(texts, t.text)
+	}
+	v.Value = strings.Join(texts, "")
+
+	return v
+}
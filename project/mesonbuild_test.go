@@ -0,0 +1,311 @@
+// SPDX-FileCopyrightText: 2021 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+package project
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	changelog "github.com/xmidt-org/gokeepachangelog"
+	"github.com/xmidt-org/release-builder-action/git"
+)
+
+func TestParseMesonProject(t *testing.T) {
+	tests := []struct {
+		description string
+		src         string
+		expected    mesonProject
+		expectedErr error
+	}{
+		{
+			description: "simple",
+			src:         "project('foo', 'c', version: '1.2.3')\n",
+			expected: mesonProject{
+				Name:    mesonValue{Value: "foo", Line: 1, Literal: true},
+				Version: mesonValue{Value: "1.2.3", Line: 1, Literal: true},
+			},
+		},
+		{
+			description: "multiple lines with comments",
+			src: `# SPDX-License-Identifier: Apache-2.0
+# project('wrong', version: '0.0.0')
+project('foo',
+        ['c', 'cpp'],
+        default_options: ['c_std=c11', 'warning_level=3'],
+        meson_version: '>=0.55.0',   # comment ) with a paren
+        license: '''Apache-2.0''',
+        version: '1.2.3-rc.1',
+)
+
+foo_dep = dependency('bar', version: '>=9.9.9')
+`,
+			expected: mesonProject{
+				Name:         mesonValue{Value: "foo", Line: 3, Literal: true},
+				Version:      mesonValue{Value: "1.2.3-rc.1", Line: 8, Literal: true},
+				MesonVersion: mesonValue{Value: ">=0.55.0", Line: 6, Literal: true},
+			},
+		},
+		{
+			description: "version is not a literal",
+			src:         "project('foo', 'c', version: run_command('cat', 'VERSION').stdout().strip())",
+			expected: mesonProject{
+				Name:    mesonValue{Value: "foo", Line: 1, Literal: true},
+				Version: mesonValue{Value: "run_command('cat','VERSION').stdout().strip()", Line: 1},
+			},
+		},
+		{
+			description: "escaped quote",
+			src:         `project('it\'s', version: '1.0.0')`,
+			expected: mesonProject{
+				Name:    mesonValue{Value: "it's", Line: 1, Literal: true},
+				Version: mesonValue{Value: "1.0.0", Line: 1, Literal: true},
+			},
+		},
+		{
+			description: "missing project",
+			src:         "executable('foo', 'foo.c')",
+			expectedErr: errMesonParse,
+		},
+		{
+			description: "project not closed",
+			src:         "project('foo', version: '1.0.0'",
+			expectedErr: errMesonParse,
+		},
+		{
+			description: "unterminated string",
+			src:         "project('foo, version: '1.0.0')",
+			expectedErr: errMesonParse,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			assert := assert.New(t)
+
+			mp, err := parseMesonProject(tc.src)
+			if tc.expectedErr == nil {
+				assert.NoError(err)
+				if assert.NotNil(mp) {
+					assert.Equal(tc.expected, *mp)
+				}
+				return
+			}
+			assert.True(errors.Is(err, tc.expectedErr),
+				fmt.Errorf("error [%v] doesn't contain error [%v] in its err chain",
+					err, tc.expectedErr),
+			)
+		})
+	}
+}
+
+func TestExamineMesonProject(t *testing.T) {
+	tests := []struct {
+		description string
+		src         string
+		commit      string
+		committed   string
+		check       string
+		expectedLog string
+		expectedErr error
+	}{
+		{
+			description: "no meson.build",
+		},
+		{
+			description: "tagged commit matches",
+			src:         "project('foo', version: '1.2.2')",
+			commit:      "abc123",
+			committed:   "project('foo', version: '1.2.3')",
+		},
+		{
+			description: "tagged commit mismatched",
+			src:         "project('foo', version: '1.2.3')",
+			commit:      "abc123",
+			committed:   "project('foo', version: '1.2.2')",
+			expectedErr: errVersionMismatch,
+		},
+		{
+			description: "no meson.build in the tagged commit",
+			src:         "project('foo', version: '1.2.2')",
+			commit:      "abc123",
+		},
+		{
+			description: "matching version",
+			src:         "project('foo', version: '1.2.3')",
+		},
+		{
+			description: "mismatched version",
+			src:         "project('foo', version: '1.2.2')",
+			expectedErr: errVersionMismatch,
+		},
+		{
+			description: "mismatched version strict",
+			src:         "project('foo', version: '1.2.2')",
+			check:       MesonCheckStrict,
+			expectedErr: errVersionMismatch,
+		},
+		{
+			description: "mismatched version warning",
+			src:         "project('foo', version: '1.2.2')",
+			check:       MesonCheckWarn,
+		},
+		{
+			description: "mismatched version check off",
+			src:         "project('foo', version: '1.2.2')",
+			check:       MesonCheckOff,
+		},
+		{
+			description: "no version",
+			src:         "project('foo', 'c')",
+			expectedLog: "The meson project has no version, there is nothing to check.",
+		},
+		{
+			description: "version not a string",
+			src:         "project('foo', version: files('VERSION'))",
+			expectedLog: "Warning: meson.build:1 has version files('VERSION'), which isn't a string and can't be checked against '1.2.3'.",
+		},
+		{
+			description: "version from a variable strict",
+			src:         "ver = '1.2.2'\nproject('foo',\n  version: ver)",
+			check:       MesonCheckStrict,
+			expectedLog: "Warning: meson.build:3 has version ver, which isn't a string and can't be checked against '1.2.3'.",
+		},
+		{
+			description: "invalid check",
+			src:         "project('foo', version: '1.2.3')",
+			check:       "sometimes",
+			expectedErr: errMesonCheckUnknown,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			assert := assert.New(t)
+
+			v, err := parseSemver("1.2.3", "")
+			require.NoError(t, err)
+
+			mockGit := &mockGit{}
+			if tc.committed != "" {
+				mockGit.On("ReadFile", tc.commit, "meson.build").Return([]byte(tc.committed), nil)
+			} else {
+				mockGit.On("ReadFile", tc.commit, "meson.build").Return(nil, git.ErrFileNotFound)
+			}
+
+			var logs []string
+			p := &Project{
+				opts: ProjectOpts{
+					BasePath: ".",
+					Log: func(format string, v ...interface{}) {
+						logs = append(logs, fmt.Sprintf(format, v...))
+					},
+					Meson: Meson{
+						VersionCheck: tc.check,
+					},
+				},
+				fs: &afero.Afero{
+					Fs: afero.NewMemMapFs(),
+				},
+				releases: []*release{
+					{tag: "v1.2.3", version: v, commit: tc.commit},
+				},
+				git: mockGit,
+			}
+			if tc.src != "" {
+				require.NoError(t, writeFile(p.fs, "meson.build", tc.src))
+			}
+
+			err = p.examineMesonProject()
+			if tc.expectedLog != "" {
+				assert.Contains(logs, tc.expectedLog)
+			}
+			if tc.expectedErr == nil {
+				assert.NoError(err)
+				return
+			}
+			assert.True(errors.Is(err, tc.expectedErr),
+				fmt.Errorf("error [%v] doesn't contain error [%v] in its err chain",
+					err, tc.expectedErr),
+			)
+		})
+	}
+}
+
+func TestGenerateMesonWrapper(t *testing.T) {
+	tests := []struct {
+		description string
+		archives    []git.Format
+		archive     string
+		expected    string
+	}{
+		{
+			description: "default archives",
+			expected:    "tar.gz",
+		}, {
+			description: "first tar archive",
+			archives:    []git.Format{{Name: "zip"}, {Name: "tar.zst"}, {Name: "tar.xz"}},
+			expected:    "tar.zst",
+		}, {
+			description: "no tar archive",
+			archives:    []git.Format{{Name: "zip"}},
+			expected:    "zip",
+		}, {
+			description: "selected archive",
+			archives:    []git.Format{{Name: "tar.gz"}, {Name: "tar.xz", Level: 9}},
+			archive:     "tar.xz",
+			expected:    "tar.xz",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			assert := assert.New(t)
+
+			fs := &afero.Afero{Fs: afero.NewMemMapFs()}
+			require.NoError(t, writeFile(fs, "meson.build", "project('bar', version: '1.2.3')"))
+
+			p := &Project{
+				opts: ProjectOpts{
+					Slug:      "foo/bar",
+					BasePath:  ".",
+					TagPrefix: "v",
+					Log:       func(string, ...interface{}) {},
+					Meson: Meson{
+						Provides: "none",
+						Archive:  tc.archive,
+					},
+					Archives: tc.archives,
+				},
+				repoName: "bar",
+				fs:       fs,
+			}
+
+			archives := map[string]string{}
+			for _, f := range p.archiveFormats() {
+				file := "./artifacts/bar-1.2.3." + f.Name
+				require.NoError(t, writeFile(fs, file, f.Name))
+				archives[f.Name] = file
+			}
+
+			r := &release{
+				rel: &changelog.Release{Version: "v1.2.3"},
+				tag: "v1.2.3",
+			}
+			require.NoError(t, p.generateMesonWrapper(r, "./artifacts", archives))
+
+			buf, err := fs.ReadFile("./artifacts/bar.wrap")
+			require.NoError(t, err)
+			sum, err := sha(fs, archives[tc.expected])
+			require.NoError(t, err)
+
+			wrap := string(buf)
+			assert.Contains(wrap, "source_filename = bar-1.2.3."+tc.expected+"\n")
+			assert.Contains(wrap, "source_url = https://github.com/foo/bar/releases/download/v1.2.3/bar-1.2.3."+tc.expected+"\n")
+			assert.Contains(wrap, fmt.Sprintf("source_hash = %x\n", sum))
+		})
+	}
+}
@@ -2,7 +2,10 @@
 // SPDX-License-Identifier: Apache-2.0
 package project
 
-import "github.com/stretchr/testify/mock"
+import (
+	"github.com/stretchr/testify/mock"
+	"github.com/xmidt-org/release-builder-action/git"
+)
 
 type mockGit struct {
 	mock.Mock
@@ -13,17 +16,109 @@ func (m *mockGit) IsTagPresent(tag string) (bool, error) {
 	return args.Bool(0), args.Error(1)
 }
 
+func (m *mockGit) Tags() ([]string, error) {
+	args := m.Called()
+	return args.Get(0).([]string), args.Error(1)
+}
+
 func (m *mockGit) TagHead(ver, msg string) error {
 	args := m.Called(ver, msg)
 	return args.Error(0)
 }
 
-func (m *mockGit) PushTags(token string) error {
-	args := m.Called(token)
+func (m *mockGit) TagCommit(ver, msg, hash string) error {
+	args := m.Called(ver, msg, hash)
+	return args.Error(0)
+}
+
+func (m *mockGit) VerifyTag(tag string) (string, error) {
+	args := m.Called(tag)
+	return args.String(0), args.Error(1)
+}
+
+func (m *mockGit) InspectTag(tag string) (git.TagInfo, error) {
+	args := m.Called(tag)
+	info, _ := args.Get(0).(git.TagInfo)
+	return info, args.Error(1)
+}
+
+func (m *mockGit) HasVersion(hash, file, ver string) (bool, error) {
+	args := m.Called(hash, file, ver)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *mockGit) ReadFile(hash, file string) ([]byte, error) {
+	args := m.Called(hash, file)
+	buf, _ := args.Get(0).([]byte)
+	return buf, args.Error(1)
+}
+
+func (m *mockGit) ListFiles(hash, dir string) ([]string, error) {
+	args := m.Called(hash, dir)
+	files, _ := args.Get(0).([]string)
+	return files, args.Error(1)
+}
+
+func (m *mockGit) ResolveCommit(rev string) (string, error) {
+	args := m.Called(rev)
+	return args.String(0), args.Error(1)
+}
+
+func (m *mockGit) FindVersionCommit(file, ver string) (string, error) {
+	args := m.Called(file, ver)
+	return args.String(0), args.Error(1)
+}
+
+func (m *mockGit) CommitToBranch(branch, msg string, files ...string) (string, error) {
+	args := m.Called(branch, msg, files)
+	return args.String(0), args.Error(1)
+}
+
+func (m *mockGit) PushBranch(token, branch string) error {
+	args := m.Called(token, branch)
 	return args.Error(0)
 }
 
-func (m *mockGit) CreateArchive(slug, ver, fmt, dir string) (string, error) {
-	args := m.Called(slug, ver, fmt, dir)
+func (m *mockGit) DeleteTag(tag string) error {
+	args := m.Called(tag)
+	return args.Error(0)
+}
+
+func (m *mockGit) MoveTag(tag, msg, hash string) (string, error) {
+	args := m.Called(tag, msg, hash)
 	return args.String(0), args.Error(1)
 }
+
+func (m *mockGit) RestoreTag(tag, object string) error {
+	args := m.Called(tag, object)
+	return args.Error(0)
+}
+
+func (m *mockGit) PushAliasTags(token string, tags ...string) error {
+	args := m.Called(token, tags)
+	return args.Error(0)
+}
+
+func (m *mockGit) PushTags(token string, tags ...string) error {
+	args := m.Called(token, tags)
+	return args.Error(0)
+}
+
+func (m *mockGit) CreateArchive(slug, ver, subdir string, fmt git.Format, dir string, extra []git.ArchiveFile) (git.Archive, error) {
+	args := m.Called(slug, ver, subdir, fmt, dir, extra)
+	if fn, ok := args.Get(0).(func(string, string, string, git.Format, string, []git.ArchiveFile) (git.Archive, error)); ok {
+		return fn(slug, ver, subdir, fmt, dir, extra)
+	}
+	return args.Get(0).(git.Archive), args.Error(1)
+}
+
+func (m *mockGit) RemoteTags(token string) (map[string]git.TagRef, error) {
+	args := m.Called(token)
+	tags, _ := args.Get(0).(map[string]git.TagRef)
+	return tags, args.Error(1)
+}
+
+func (m *mockGit) IsShallow() (bool, error) {
+	args := m.Called()
+	return args.Bool(0), args.Error(1)
+}
@@ -0,0 +1,206 @@
+// SPDX-FileCopyrightText: 2021 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+package project
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+var (
+	errUnreleasedMissing = errors.New("the unreleased section is missing")
+	errUnreleasedEmpty   = errors.New("the unreleased section is empty")
+	errPrepareVersion    = errors.New("either a bump kind or a version must be specified")
+)
+
+// Prepare describes how the next release is prepared from the unreleased
+// section of the changelog.
+type Prepare struct {
+	// Bump is one of major, minor, patch, prerelease or auto.  Auto uses the
+	// smallest bump the unreleased changes need.
+	Bump string
+
+	// Version is an explicit version to use instead of Bump.
+	Version string
+
+	// PrereleaseID is the identifier used when starting a new prerelease.
+	PrereleaseID string
+
+	// Component is the name of the component to prepare when the project
+	// has components.
+	Component string
+}
+
+// prepared is the release prepared by Prepare.
+type prepared struct {
+	tag    string
+	branch string
+}
+
+// Prepare promotes the unreleased section of the changelog into a new dated
+// release section, leaves an empty unreleased section and updates the links.
+// The change is committed to a new release branch and pushed so it can be
+// merged, which then triggers the normal release.  During a dry run the
+// changes are only logged as a diff.
+func (p *Project) Prepare() error {
+	if len(p.components) == 0 {
+		return p.prepare()
+	}
+
+	c, err := p.findComponent(p.opts.Prepare.Component)
+	if err != nil {
+		return err
+	}
+	if err = c.prepare(); err != nil {
+		return err
+	}
+	p.prepared = c.prepared
+
+	return nil
+}
+
+func (p *Project) prepare() error {
+	p.opts.Log("Processing the %s file.", p.opts.ChangelogFile)
+	if err := p.processChangelog(); err != nil {
+		return err
+	}
+
+	v, err := p.prepareVersion()
+	if err != nil {
+		return err
+	}
+	tag := p.opts.TagPrefix + v.String()
+	p.opts.Log("Prepairing the release: %s.", tag)
+
+	old := p.changelogLines
+	lines, err := promoteUnreleased(old, tag, time.Now().Format("2006-01-02"))
+	if err != nil {
+		return err
+	}
+
+	// Parse the promoted changelog so the links include the new release.
+	path := p.opts.BasePath + "/" + p.opts.ChangelogFile
+	if err = p.parseChangelog([]byte(strings.Join(lines, "\n")+"\n"), path); err != nil {
+		return err
+	}
+	if lines, err = p.fixedLinks(lines); err != nil {
+		return err
+	}
+
+	p.opts.Log("%s", unifiedDiff(p.opts.ChangelogFile, old, lines))
+
+	branch := "release/" + tag
+	p.prepared = &prepared{
+		tag:    tag,
+		branch: branch,
+	}
+
+	if p.dryRun {
+		p.opts.Log("This is a dry run, do not alter the repo.")
+		return nil
+	}
+
+	p.opts.Log("Updating the %s file.", p.opts.ChangelogFile)
+	if err = p.writeChangelog(lines); err != nil {
+		return err
+	}
+
+	p.opts.Log("Committing the changelog to the %s branch.", branch)
+	if _, err = p.git.CommitToBranch(branch, "Prepare the release: "+tag, p.opts.ChangelogFile); err != nil {
+		return err
+	}
+
+	p.opts.Log("Pushing the %s branch to the upstream repository.", branch)
+	return p.git.PushBranch(p.opts.Token, branch)
+}
+
+// prepareVersion returns the version of the release being prepared, which
+// must be newer than the newest release in the changelog.
+func (p *Project) prepareVersion() (semver, error) {
+	var latest semver
+	var latestVersion string
+	for _, rel := range p.changelog.Releases {
+		if isUnreleased(rel.Version) {
+			continue
+		}
+
+		v, err := p.parseVersion(rel.Version)
+		if err != nil {
+			return v, err
+		}
+		latest, latestVersion = v, rel.Version
+		break
+	}
+
+	opts := p.opts.Prepare
+	if opts.Version != "" {
+		v, err := p.parseVersion(opts.Version)
+		if err != nil {
+			return v, err
+		}
+		if latestVersion != "" && v.Compare(latest) <= 0 {
+			return v, fmt.Errorf("%w: '%s' is not newer than '%s'", errVersionOrder, opts.Version, latestVersion)
+		}
+		return v, nil
+	}
+
+	if opts.Bump == "" {
+		return latest, errPrepareVersion
+	}
+
+	if opts.Bump == "auto" {
+		if err := p.suggestNext(); err != nil {
+			return latest, err
+		}
+		if p.suggested == nil {
+			return latest, errUnreleasedEmpty
+		}
+		return p.suggested.version, nil
+	}
+
+	preid := opts.PrereleaseID
+	if preid == "" {
+		preid = "rc"
+	}
+
+	return latest.bump(opts.Bump, preid)
+}
+
+// promoteUnreleased moves the body of the unreleased section into a new
+// release section directly below it.
+func promoteUnreleased(lines []string, tag, date string) ([]string, error) {
+	raw := scanChangelog(lines)
+
+	var unreleased *section
+	for i := range raw.sections {
+		if isUnreleased(raw.sections[i].label) {
+			unreleased = &raw.sections[i]
+			break
+		}
+	}
+	if unreleased == nil {
+		return nil, errUnreleasedMissing
+	}
+	if !unreleased.content {
+		return nil, errUnreleasedEmpty
+	}
+
+	body := lines[unreleased.line+1 : unreleased.end]
+	for len(body) > 0 && strings.TrimSpace(body[0]) == "" {
+		body = body[1:]
+	}
+	for len(body) > 0 && strings.TrimSpace(body[len(body)-1]) == "" {
+		body = body[:len(body)-1]
+	}
+
+	promoted := make([]string, 0, len(lines)+4)
+	promoted = append(promoted, lines[:unreleased.line+1]...)
+	promoted = append(promoted, "", "## ["+tag+"] - "+date)
+	promoted = append(promoted, body...)
+	promoted = append(promoted, "")
+	promoted = append(promoted, lines[unreleased.end:]...)
+
+	return promoted, nil
+}
@@ -0,0 +1,153 @@
+// SPDX-FileCopyrightText: 2021 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+package project
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	pendingChangelog = `# Changelog
+
+## [Unreleased]
+### Added
+- new thing
+
+## [v1.1.0]
+### Added
+- example
+
+[Unreleased]: https://github.com/foo/bar/compare/v1.1.0...HEAD
+[v1.1.0]: https://github.com/foo/bar/releases/tag/v1.1.0
+`
+	preparedChangelog = `# Changelog
+
+## [Unreleased]
+
+## [v1.2.0] - %s
+### Added
+- new thing
+
+## [v1.1.0]
+### Added
+- example
+
+[Unreleased]: https://github.com/foo/bar/compare/v1.2.0...HEAD
+[v1.2.0]: https://github.com/foo/bar/compare/v1.1.0...v1.2.0
+[v1.1.0]: https://github.com/foo/bar/releases/tag/v1.1.0
+`
+)
+
+func TestPrepare(t *testing.T) {
+	errTest := errors.New("test error")
+	tests := []struct {
+		description string
+		contents    string
+		prepare     Prepare
+		dryrun      bool
+		pushErr     error
+		expected    string
+		expectedErr error
+	}{
+		{
+			description: "bump minor",
+			contents:    pendingChangelog,
+			prepare:     Prepare{Bump: "minor"},
+			expected:    preparedChangelog,
+		},
+		{
+			description: "explicit version",
+			contents:    pendingChangelog,
+			prepare:     Prepare{Version: "1.2.0"},
+			expected:    preparedChangelog,
+		},
+		{
+			description: "dry run",
+			contents:    pendingChangelog,
+			prepare:     Prepare{Bump: "minor"},
+			dryrun:      true,
+			expected:    pendingChangelog,
+		},
+		{
+			description: "explicit version is too old",
+			contents:    pendingChangelog,
+			prepare:     Prepare{Version: "1.1.0"},
+			expectedErr: errVersionOrder,
+		},
+		{
+			description: "no bump or version",
+			contents:    pendingChangelog,
+			expectedErr: errPrepareVersion,
+		},
+		{
+			description: "nothing to release",
+			contents:    cleanChangelog,
+			prepare:     Prepare{Bump: "patch"},
+			expectedErr: errUnreleasedEmpty,
+		},
+		{
+			description: "push fails",
+			contents:    pendingChangelog,
+			prepare:     Prepare{Bump: "minor"},
+			pushErr:     errTest,
+			expectedErr: errTest,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			assert := assert.New(t)
+
+			mockGit := &mockGit{}
+			mockGit.On("CommitToBranch", "release/v1.2.0", mock.Anything, []string{"CHANGELOG.md"}).Return("abc123", nil)
+			mockGit.On("PushBranch", "token", "release/v1.2.0").Return(tc.pushErr)
+			mockGit.On("IsTagPresent", mock.Anything).Return(false, nil)
+
+			p := &Project{
+				opts: ProjectOpts{
+					Slug:          "foo/bar",
+					Token:         "token",
+					ChangelogFile: "CHANGELOG.md",
+					BasePath:      ".",
+					TagPrefix:     "v",
+					Log:           func(string, ...interface{}) {},
+					Prepare:       tc.prepare,
+				},
+				dryRun: tc.dryrun,
+				fs: &afero.Afero{
+					Fs: afero.NewMemMapFs(),
+				},
+				git: mockGit,
+			}
+			require.NoError(t, writeFile(p.fs, "CHANGELOG.md", tc.contents))
+
+			err := p.Prepare()
+			if tc.expectedErr != nil {
+				assert.True(errors.Is(err, tc.expectedErr),
+					fmt.Errorf("error [%v] doesn't contain error [%v] in its err chain",
+						err, tc.expectedErr),
+				)
+				return
+			}
+			assert.NoError(err)
+
+			expected := tc.expected
+			if !tc.dryrun {
+				expected = fmt.Sprintf(tc.expected, time.Now().Format("2006-01-02"))
+				mockGit.AssertExpectations(t)
+			}
+
+			got, err := p.fs.ReadFile("./CHANGELOG.md")
+			require.NoError(t, err)
+			assert.Equal(expected, string(got))
+		})
+	}
+}
@@ -3,8 +3,12 @@
 package project
 
 import (
+	"bytes"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"path"
+	"strconv"
 	"strings"
 	"time"
 
@@ -15,7 +19,16 @@ import (
 )
 
 const (
-	releaseBodyFile = ".release-body.md"
+	releaseBodyFile = ".release-body"
+)
+
+const (
+	// TagTargetHead tags the head of the repo.
+	TagTargetHead = "head"
+
+	// TagTargetChangelog tags the commit that added the release heading to
+	// the changelog.
+	TagTargetChangelog = "changelog"
 )
 
 var (
@@ -26,37 +39,96 @@ var (
 	errSHAFileMissing     = errors.New("shasum-file must be specified")
 	errRepoFormatError    = errors.New("the slug format is invalid")
 	errPathNotDirectory   = errors.New("path is not a directory")
-	//errVersionMismatch    = errors.New("the versions do not match")
+	errVersionDuplicate   = errors.New("the version is listed more than once")
+	errVersionOrder       = errors.New("the releases are not in descending order")
+	errVersionNotNewer    = errors.New("the version is not newer than the newest tag")
+	errVersionMismatch    = errors.New("the versions do not match")
+	errTagTargetUnknown   = errors.New("the tag target must be head or changelog")
+	errShallowClone       = errors.New("the repository is a shallow clone and the upstream tags are unavailable")
+	errArchiveDuplicate   = errors.New("the archive format is listed more than once")
 )
 
 type ProjectOpts struct {
-	Slug          string
-	BasePath      string
-	Token         string
-	TagPrefix     string
-	ChangelogFile string
-	ArtifactDir   string
-	SHASumFile    string
-	Log           func(string, ...interface{})
-	Meson         Meson
+	Slug               string
+	BasePath           string
+	Token              string
+	TagPrefix          string
+	ChangelogFile      string
+	ArtifactDir        string
+	SHASumFile         string
+	ReleaseAll         bool
+	TagTarget          string
+	Ref                string
+	LocalOnly          bool
+	Log                func(string, ...interface{})
+	Meson              Meson
+	Archives           []git.Format
+	Submodules         bool
+	LFS                bool
+	Dist               Dist
+	VersionFiles       []string
+	AliasTags          []string
+	Tagging            git.Options
+	Prepare            Prepare
+	VerifyVersion      string
+	InitialDevelopment bool
+	Components         []Component
 }
 
 type GitIF interface {
 	IsTagPresent(string) (bool, error)
+	Tags() ([]string, error)
 	TagHead(string, string) error
-	PushTags(string) error
-	CreateArchive(string, string, string, string) (string, error)
+	TagCommit(string, string, string) error
+	DeleteTag(string) error
+	MoveTag(string, string, string) (string, error)
+	RestoreTag(string, string) error
+	VerifyTag(string) (string, error)
+	InspectTag(string) (git.TagInfo, error)
+	HasVersion(string, string, string) (bool, error)
+	ReadFile(string, string) ([]byte, error)
+	ListFiles(string, string) ([]string, error)
+	FindVersionCommit(string, string) (string, error)
+	ResolveCommit(string) (string, error)
+	CommitToBranch(string, string, ...string) (string, error)
+	PushBranch(string, string) error
+	PushTags(string, ...string) error
+	PushAliasTags(string, ...string) error
+	RemoteTags(string) (map[string]git.TagRef, error)
+	IsShallow() (bool, error)
+	CreateArchive(string, string, string, git.Format, string, []git.ArchiveFile) (git.Archive, error)
 }
 
 type Project struct {
-	opts        ProjectOpts
-	dryRun      bool
-	org         string
-	repoName    string
-	fs          *afero.Afero
-	changelog   *changelog.Changelog
-	nextRelease *changelog.Release
-	git         GitIF
+	opts           ProjectOpts
+	dryRun         bool
+	org            string
+	repoName       string
+	fs             *afero.Afero
+	changelog      *changelog.Changelog
+	changelogLines []string
+	nextRelease    *changelog.Release
+	releases       []*release
+	prepared       *prepared
+	suggested      *suggestion
+	component      *Component
+	components     []*Project
+	nested         []string
+	remoteTags     map[string]git.TagRef
+	tagReport      []tagReport
+	git            GitIF
+}
+
+// release is a changelog release that has not been tagged yet along with
+// where the artifacts for it are placed.
+type release struct {
+	rel      *changelog.Release
+	version  semver
+	tag      string
+	commit   string
+	artDir   string
+	bodyFile string
+	aliases  []string
 }
 
 func NewProject(opts ProjectOpts, dryrun bool) (*Project, error) {
@@ -78,7 +150,26 @@ func NewProject(opts ProjectOpts, dryrun bool) (*Project, error) {
 		return nil, fmt.Errorf("%w: '%s' invalid", errRepoFormatError, opts.Slug)
 	}
 
-	if !dryrun && opts.Token == "" {
+	formats := opts.Archives
+	if len(formats) == 0 {
+		formats = git.DefaultFormats
+	}
+	seen := map[string]bool{}
+	for _, f := range formats {
+		if seen[f.Name] {
+			return nil, fmt.Errorf("%w: '%s'", errArchiveDuplicate, f.Name)
+		}
+		seen[f.Name] = true
+	}
+	if opts.Meson.Archive != "" && !seen[opts.Meson.Archive] {
+		return nil, fmt.Errorf("%w: '%s'", errMesonArchive, opts.Meson.Archive)
+	}
+	if err := validateAliases(opts.AliasTags); err != nil {
+		return nil, err
+	}
+
+	// The token is only needed to push to the upstream repo.
+	if !dryrun && !opts.LocalOnly && opts.Token == "" {
 		return nil, errTokenMissing
 	}
 
@@ -96,37 +187,88 @@ func NewProject(opts ProjectOpts, dryrun bool) (*Project, error) {
 	}
 
 	// Open existing git repo
-	g, err := git.Open(p.opts.BasePath)
+	gopts := p.opts.Tagging
+	gopts.Submodules = p.opts.Submodules
+	gopts.LFS = p.opts.LFS
+	g, err := git.Open(p.opts.BasePath, gopts)
 	if err != nil {
 		return nil, fmt.Errorf("%w: unable to open the git path: '%s'", err, p.opts.BasePath)
 	}
 	p.git = g
 
+	for _, c := range p.opts.Components {
+		child, err := p.newComponent(c)
+		if err != nil {
+			return nil, err
+		}
+		p.components = append(p.components, child)
+	}
+
 	return &p, nil
 }
 
 // ExamineProject
 func (p *Project) ExamineProject() error {
+	if err := p.examineRemote(); err != nil {
+		return err
+	}
+
+	for _, c := range p.projects() {
+		c.remoteTags = p.remoteTags
+		if err := c.examine(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (p *Project) examine() error {
+	if p.component != nil {
+		p.opts.Log("Examining the %s component.", p.component.Name)
+	}
+
 	p.opts.Log("Processing the %s file.", p.opts.ChangelogFile)
 	if err := p.processChangelog(); err != nil {
 		return err
 	}
 
+	if err := p.suggestNext(); err != nil {
+		return err
+	}
+
 	p.opts.Log("Examining the git repo tags.")
 	if err := p.examineTags(); err != nil {
 		return err
 	}
 
+	if err := p.checkTagCommits(); err != nil {
+		return err
+	}
+
+	if err := p.verifyTags(); err != nil {
+		return err
+	}
+
 	if !p.FoundNewRelease() {
 		p.opts.Log("No new release found.")
 		return nil
 	}
 
-	return p.examineMesonProject()
+	if err := p.examineMesonProject(); err != nil {
+		return err
+	}
+
+	return p.examineVersionFiles()
 }
 
 func (p *Project) FoundNewRelease() bool {
-	return p.nextRelease != nil
+	for _, c := range p.projects() {
+		if c.nextRelease != nil {
+			return true
+		}
+	}
+	return false
 }
 
 func (p *Project) Release() error {
@@ -135,116 +277,593 @@ func (p *Project) Release() error {
 		return nil
 	}
 
-	p.opts.Log("Prepairing the release: %s.", p.nextRelease.Version)
+	for _, c := range p.projects() {
+		for _, r := range c.releases {
+			if r.version.IsPrerelease() {
+				p.opts.Log("Prepairing the prerelease: %s.", r.tag)
+				continue
+			}
+			p.opts.Log("Prepairing the release: %s.", r.tag)
+		}
+	}
 
 	if p.dryRun {
 		p.opts.Log("This is a dry run, do not alter the repo or create artifacts.")
 		return nil
 	}
 
-	p.opts.Log("Tagging the repository.")
-	v := p.nextRelease.Version
-	if err := p.git.TagHead(v, "Releasing: "+v); err != nil {
-		return err
+	// Every change made from here on is undone if a later step fails.
+	tx := &transaction{log: p.opts.Log}
+
+	var tags, aliases []string
+	for _, c := range p.projects() {
+		// Release the oldest version first so the tags are created in order.
+		for i := len(c.releases) - 1; i >= 0; i-- {
+			if err := c.buildRelease(tx, c.releases[i]); err != nil {
+				return tx.rollback(err)
+			}
+			tags = append(tags, c.releases[i].tag)
+		}
+
+		for _, r := range c.releases {
+			if err := tx.trackFile(c.fs, r.bodyFile); err != nil {
+				return tx.rollback(err)
+			}
+			if err := c.writeBodyFile(r); err != nil {
+				return tx.rollback(err)
+			}
+		}
+
+		moved, err := c.moveAliases(tx)
+		if err != nil {
+			return tx.rollback(err)
+		}
+		aliases = append(aliases, moved...)
+	}
+
+	// Only the tags created here are pushed so stray local tags stay local.
+	p.opts.Log("Pushing the tags %s to the upstream repository.", strings.Join(tags, ", "))
+	if err := p.git.PushTags(p.opts.Token, tags...); err != nil {
+		// Tags that reached the upstream repo are published, so the release
+		// isn't undone.
+		if errors.Is(err, git.ErrPushIncomplete) {
+			return err
+		}
+		return tx.rollback(err)
+	}
+
+	// The release tags are upstream now, so a failure isn't rolled back.
+	if len(aliases) > 0 {
+		p.opts.Log("Pushing the alias tags %s to the upstream repository.", strings.Join(aliases, ", "))
+		if err := p.git.PushAliasTags(p.opts.Token, aliases...); err != nil {
+			return fmt.Errorf("%w: the release tags were pushed but the alias tags were not", err)
+		}
+	}
+
+	return nil
+}
+
+// archiveFormats returns the archive formats to create for each release.
+func (p *Project) archiveFormats() []git.Format {
+	if len(p.opts.Archives) == 0 {
+		return git.DefaultFormats
 	}
+	return p.opts.Archives
+}
+
+func (p *Project) buildRelease(tx *transaction, r *release) error {
+	v := r.tag
+
+	if r.commit == "" {
+		p.opts.Log("Tagging the repository with %s.", v)
+		if err := p.git.TagHead(v, "Releasing: "+v); err != nil {
+			return err
+		}
+	} else {
+		p.opts.Log("Tagging commit %s with %s.", r.commit, v)
+		if err := p.git.TagCommit(v, "Releasing: "+v, r.commit); err != nil {
+			return err
+		}
+	}
+	tx.onRollback("the tag "+v, func() error {
+		return p.git.DeleteTag(v)
+	})
 
 	// Make the artifact dir if needed
 	p.opts.Log("Ensuring the artifact directory is present.")
-	artDir := p.opts.BasePath + "/" + p.opts.ArtifactDir
+	artDir := p.opts.BasePath + "/" + r.artDir
+	if err := tx.trackDir(p.fs, artDir); err != nil {
+		return err
+	}
 	if err := mkdir(p.fs, artDir); err != nil {
 		return err
 	}
 
-	slug := p.getReleaseSlug()
-	p.opts.Log("Creating the zip archive.")
-	_, err := p.git.CreateArchive(slug, p.nextRelease.Version, "zip", artDir)
+	slug := p.getReleaseSlug(r.rel)
+	subdir := p.componentPath()
+	extra, err := p.distFiles(v, r.version)
 	if err != nil {
 		return err
 	}
-	p.opts.Log("Creating the tar.gz archive.")
-	tgz, err := p.git.CreateArchive(slug, p.nextRelease.Version, "tar.gz", artDir)
-	if err != nil {
-		return err
+
+	archives := map[string]string{}
+	var a git.Archive
+	for _, f := range p.archiveFormats() {
+		p.opts.Log("Creating the %s archive.", f)
+		if a, err = p.git.CreateArchive(slug, v, subdir, f, artDir, extra); err != nil {
+			return err
+		}
+		archives[f.Name] = a.File
 	}
 
-	if err = p.generateMesonWrapper(artDir, tgz); err != nil {
-		return err
+	// Every archive has the same contents, so the last one is reported.
+	for _, path := range a.Added {
+		p.opts.Log("Added to the archives: %s", path)
+	}
+	for _, path := range a.LFS {
+		p.opts.Log("Resolved from Git LFS: %s", path)
+	}
+	for _, path := range a.Excluded {
+		p.opts.Log("Excluded by export-ignore: %s", path)
+	}
+	for _, path := range a.Substituted {
+		p.opts.Log("Expanded by export-subst: %s", path)
 	}
 
-	p.opts.Log("Creating the sha256sum file.")
-	if err = generateSha256Sum(p.fs, p.opts.SHASumFile, artDir); err != nil {
+	if err = p.generateMesonWrapper(r, artDir, archives); err != nil {
 		return err
 	}
 
-	p.opts.Log("Pushing the tags to the upstream repository.")
-	return p.git.PushTags(p.opts.Token)
+	p.opts.Log("Creating the sha256sum file.")
+	return generateSha256Sum(p.fs, p.opts.SHASumFile, artDir)
+}
+
+// releaseOutput is the per release information provided in the releases
+// output.
+type releaseOutput struct {
+	Component   string   `json:"component,omitempty"`
+	Tag         string   `json:"tag"`
+	Name        string   `json:"name"`
+	BodyFile    string   `json:"body-file"`
+	ArtifactDir string   `json:"artifact-dir"`
+	Prerelease  bool     `json:"prerelease"`
+	Aliases     []string `json:"aliases,omitempty"`
 }
 
 func (p *Project) OutputData() error {
-	if p.FoundNewRelease() {
-		v := p.nextRelease.Version
-		now := time.Now().Format("2006-01-02")
-
-		if !p.dryRun {
-			f, err := p.fs.Create(releaseBodyFile)
-			if err != nil {
-				return fmt.Errorf("%w: unable to create file '%s'", err, releaseBodyFile)
-			}
-			defer f.Close()
-			for _, line := range p.nextRelease.Body[1:] {
-				_, err = fmt.Fprintln(f, line)
-				if err != nil {
-					return fmt.Errorf("%w: unable to write to file '%s'", err, releaseBodyFile)
-				}
+	if p.prepared != nil {
+		gh.SetOutput("prepare-tag", p.prepared.tag)
+		gh.SetOutput("prepare-branch", p.prepared.branch)
+	}
+
+	if p.suggested != nil {
+		gh.SetOutput("suggested-bump", bumpNames[p.suggested.bump])
+		gh.SetOutput("suggested-version", p.opts.TagPrefix+p.suggested.version.String())
+	}
+
+	if len(p.components) > 0 {
+		if err := p.outputComponents(); err != nil {
+			return err
+		}
+	}
+
+	if err := p.outputTagReport(); err != nil {
+		return err
+	}
+
+	if !p.FoundNewRelease() {
+		return nil
+	}
+
+	now := time.Now().Format("2006-01-02")
+
+	var first *release
+	var outputs []releaseOutput
+	aliases := []string{}
+	for _, c := range p.projects() {
+		for _, r := range c.releases {
+			if first == nil {
+				first = r
 			}
+			outputs = append(outputs, c.releaseOutput(r, now))
+			aliases = append(aliases, r.aliases...)
 		}
+	}
+
+	all, err := json.Marshal(outputs)
+	if err != nil {
+		return fmt.Errorf("%w: unable to encode the releases output", err)
+	}
 
-		gh.SetOutput("release-tag", v)
-		gh.SetOutput("release-name", v+" "+now)
-		gh.SetOutput("release-body-file", releaseBodyFile)
-		gh.SetOutput("artifact-dir", p.opts.ArtifactDir)
+	moved, err := json.Marshal(aliases)
+	if err != nil {
+		return fmt.Errorf("%w: unable to encode the alias tags output", err)
 	}
+
+	// The single release outputs always describe the newest release of the
+	// first component with a release.
+	newest := outputs[0]
+	gh.SetOutput("release-tag", newest.Tag)
+	gh.SetOutput("release-name", newest.Name)
+	gh.SetOutput("release-body-file", newest.BodyFile)
+	gh.SetOutput("artifact-dir", newest.ArtifactDir)
+	gh.SetOutput("releases", string(all))
+	gh.SetOutput("alias-tags", string(moved))
+
+	v := first.version
+	gh.SetOutput("is-prerelease", strconv.FormatBool(v.IsPrerelease()))
+	gh.SetOutput("version-core", v.Core())
+	gh.SetOutput("version-major", strconv.FormatUint(v.Major, 10))
+	gh.SetOutput("version-minor", strconv.FormatUint(v.Minor, 10))
+	gh.SetOutput("version-patch", strconv.FormatUint(v.Patch, 10))
+	gh.SetOutput("version-prerelease", strings.Join(v.Prerelease, "."))
+	gh.SetOutput("version-build", strings.Join(v.Build, "."))
+
 	return nil
 }
 
-func (p *Project) getReleaseSlug() string {
-	return p.repoName + "-" + strings.TrimPrefix(p.nextRelease.Version, p.opts.TagPrefix)
+func (p *Project) releaseOutput(r *release, date string) releaseOutput {
+	out := releaseOutput{
+		Tag:         r.tag,
+		Name:        releaseName(r, date),
+		BodyFile:    r.bodyFile,
+		ArtifactDir: r.artDir,
+		Prerelease:  r.version.IsPrerelease(),
+		Aliases:     r.aliases,
+	}
+	if p.component != nil {
+		out.Component = p.component.Name
+	}
+	return out
+}
+
+// releaseName returns the name of the release, which is marked when the
+// release is a prerelease.
+func releaseName(r *release, date string) string {
+	name := r.tag + " " + date
+	if r.version.IsPrerelease() {
+		name += " (prerelease)"
+	}
+	return name
+}
+
+func (p *Project) writeBodyFile(r *release) error {
+	f, err := p.fs.Create(r.bodyFile)
+	if err != nil {
+		return fmt.Errorf("%w: unable to create file '%s'", err, r.bodyFile)
+	}
+	defer f.Close()
+
+	if len(r.rel.Body) == 0 {
+		return nil
+	}
+
+	for _, line := range r.rel.Body[1:] {
+		_, err = fmt.Fprintln(f, line)
+		if err != nil {
+			return fmt.Errorf("%w: unable to write to file '%s'", err, r.bodyFile)
+		}
+	}
+
+	return nil
 }
 
+// bodyFile returns the name of the release body file, which includes the
+// component name and tag when needed to keep the file unique.
+func (p *Project) bodyFile(tag string) string {
+	name := releaseBodyFile
+	if p.component != nil {
+		name += "-" + p.component.Name
+	}
+	if tag != "" {
+		name += "-" + strings.ReplaceAll(tag, "/", "-")
+	}
+	return name + ".md"
+}
+
+func (p *Project) getReleaseSlug(rel *changelog.Release) string {
+	v, err := p.parseVersion(rel.Version)
+	if err != nil {
+		return p.repoName + "-" + strings.TrimPrefix(rel.Version, p.opts.TagPrefix)
+	}
+	return p.repoName + "-" + v.String()
+}
+
+// tagName returns the canonical tag for a changelog release, which is the tag
+// prefix followed by the semantic version.  The changelog heading may be
+// written with or without the prefix.
+func (p *Project) tagName(rel *changelog.Release) (string, semver, error) {
+	v, err := p.parseVersion(rel.Version)
+	if err != nil {
+		return "", v, err
+	}
+
+	return p.opts.TagPrefix + v.String(), v, nil
+}
+
+// isTagPresent returns true if the release is tagged using either the
+// canonical tag or the bare version.  Components only use the canonical tag
+// since the bare version is shared by every component.
+func (p *Project) isTagPresent(tag string, v semver) (bool, error) {
+	name, err := p.findTag(tag, v)
+	return name != "", err
+}
+
+// findTag returns the name of the tag used for the release or an empty
+// string if the release is not tagged.
+func (p *Project) findTag(tag string, v semver) (string, error) {
+	names := []string{tag, v.String()}
+	if p.component != nil {
+		names = names[:1]
+	}
+
+	for _, name := range names {
+		if _, found := p.remoteTags[name]; found {
+			return name, nil
+		}
+
+		present, err := p.git.IsTagPresent(name)
+		if err != nil {
+			return "", err
+		}
+		if present {
+			return name, nil
+		}
+	}
+
+	return "", nil
+}
+
+// examineTags finds the changelog releases that are newer than the newest
+// tagged release.  Normally only the newest changelog release is considered,
+// but if ReleaseAll is set every untagged release above the newest tagged
+// release is collected.
 func (p *Project) examineTags() error {
+	p.nextRelease = nil
+	p.releases = nil
+
 	// Map changelog and git releases
-	for _, rel := range p.changelog.Releases {
+	for i := range p.changelog.Releases {
+		rel := &p.changelog.Releases[i]
 		if "unreleased" == strings.ToLower(rel.Version) {
 			continue
 		}
 
-		present, err := p.git.IsTagPresent(rel.Version)
+		tag, v, err := p.tagName(rel)
+		if err != nil {
+			return err
+		}
+
+		present, err := p.isTagPresent(tag, v)
 		if err != nil {
 			return fmt.Errorf("%w: unable to process git repo", err)
 		}
 
-		if !present {
-			p.nextRelease = &rel
-			return nil
+		if present {
+			break
+		}
+
+		p.releases = append(p.releases, &release{
+			rel:     rel,
+			version: v,
+			tag:     tag,
+		})
+		if !p.opts.ReleaseAll {
+			break
 		}
-		break
+	}
+
+	if len(p.releases) == 0 {
+		return nil
+	}
+	p.nextRelease = p.releases[0].rel
+
+	if err := p.checkNewerThanTags(p.releases[len(p.releases)-1]); err != nil {
+		return err
+	}
+
+	if err := p.findTagTarget(p.releases[0]); err != nil {
+		return err
+	}
+
+	if !p.opts.ReleaseAll {
+		p.releases[0].artDir = p.opts.ArtifactDir
+		p.releases[0].bodyFile = p.bodyFile("")
+		return nil
+	}
+
+	// Each release gets a dedicated artifact directory and body file.  All
+	// but the newest release are tagged on the commit that introduced them
+	// into the changelog.
+	for i, r := range p.releases {
+		r.artDir = p.opts.ArtifactDir + "/" + r.tag
+		r.bodyFile = p.bodyFile(r.tag)
+
+		if i == 0 {
+			continue
+		}
+
+		commit, err := p.findVersionCommit(r)
+		if err != nil {
+			return err
+		}
+		r.commit = commit
+	}
+
+	return nil
+}
+
+// findTagTarget determines the commit the newest release is tagged on.  An
+// explicit ref is used over the tag target setting.  An empty commit means
+// the head of the repo.
+func (p *Project) findTagTarget(r *release) error {
+	if p.opts.Ref != "" {
+		commit, err := p.git.ResolveCommit(p.opts.Ref)
+		if err != nil {
+			return fmt.Errorf("%w: unable to resolve the ref '%s'", err, p.opts.Ref)
+		}
+		p.opts.Log("Using commit %s from ref %s for %s.", commit, p.opts.Ref, r.tag)
+		r.commit = commit
+		return nil
+	}
+
+	switch p.opts.TagTarget {
+	case "", TagTargetHead:
+		return nil
+	case TagTargetChangelog:
+	default:
+		return fmt.Errorf("%w: '%s'", errTagTargetUnknown, p.opts.TagTarget)
+	}
+
+	commit, err := p.findVersionCommit(r)
+	if err != nil {
+		return err
+	}
+	p.opts.Log("Using commit %s that added %s to the changelog.", commit, r.tag)
+	r.commit = commit
+
+	return nil
+}
+
+// findVersionCommit returns the commit that added the release heading to
+// the changelog.
+func (p *Project) findVersionCommit(r *release) (string, error) {
+	commit, err := p.git.FindVersionCommit(p.opts.ChangelogFile, r.rel.Version)
+	if err != nil {
+		return "", fmt.Errorf("%w: unable to find the commit for '%s'", err, r.rel.Version)
+	}
+	return commit, nil
+}
+
+// readReleaseFile returns the contents of the file, which is relative to the
+// base path, from the commit the release is tagged on.  The working tree is
+// read when the release is tagged at the head.  Missing files aren't found
+// instead of being an error.
+func (p *Project) readReleaseFile(r *release, file string) ([]byte, bool, error) {
+	if r.commit == "" {
+		full := path.Join(p.opts.BasePath, file)
+		found, err := p.fs.Exists(full)
+		if err != nil || !found {
+			return nil, false, err
+		}
+
+		buf, err := p.fs.ReadFile(full)
+		if err != nil {
+			return nil, false, fmt.Errorf("%w: unable to read file '%s'", err, full)
+		}
+		return buf, true, nil
+	}
+
+	buf, err := p.git.ReadFile(r.commit, file)
+	if err != nil {
+		if errors.Is(err, git.ErrFileNotFound) {
+			return nil, false, nil
+		}
+		return nil, false, fmt.Errorf("%w: unable to read file '%s'", err, file)
+	}
+
+	return buf, true, nil
+}
+
+// checkNewerThanTags ensures the release is newer than every tag in the repo
+// that is a semantic version.
+func (p *Project) checkNewerThanTags(r *release) error {
+	tags, err := p.allTags()
+	if err != nil {
+		return err
+	}
+
+	for _, tag := range tags {
+		tv, err := parseSemver(tag, p.opts.TagPrefix)
+		if err != nil {
+			// Tags that are not versions are not releases.
+			continue
+		}
+		if r.version.Compare(tv) <= 0 {
+			return fmt.Errorf("%w: '%s' is not newer than tag '%s'", errVersionNotNewer, r.tag, tag)
+		}
+	}
+
+	return nil
+}
+
+// validateVersions ensures every changelog release is a unique semantic
+// version and that the releases are listed newest first.
+func (p *Project) validateVersions() error {
+	var prev *semver
+	var prevVersion string
+	seen := map[string]string{}
+
+	for _, rel := range p.changelog.Releases {
+		if "unreleased" == strings.ToLower(rel.Version) {
+			continue
+		}
+
+		v, err := p.parseVersion(rel.Version)
+		if err != nil {
+			return err
+		}
+
+		// Build metadata doesn't count when comparing versions.
+		key := v
+		key.Build = nil
+		if dup, found := seen[key.String()]; found {
+			return fmt.Errorf("%w: '%s' and '%s'", errVersionDuplicate, dup, rel.Version)
+		}
+		seen[key.String()] = rel.Version
+
+		if prev != nil && prev.Compare(v) <= 0 {
+			return fmt.Errorf("%w: '%s' is listed before '%s'", errVersionOrder, prevVersion, rel.Version)
+		}
+		prev = &v
+		prevVersion = rel.Version
 	}
 
 	return nil
 }
 
 func (p *Project) processChangelog() error {
+	buf, path, err := p.readChangelog()
+	if err != nil {
+		return err
+	}
+
+	return p.parseChangelog(buf, path)
+}
+
+// readChangelog returns the contents of the changelog file and its path.
+func (p *Project) readChangelog() ([]byte, string, error) {
 	path := p.opts.BasePath + "/" + p.opts.ChangelogFile
-	f, err := p.fs.Open(path)
+	buf, err := p.fs.ReadFile(path)
 	if err != nil {
-		return fmt.Errorf("%w: unable to open the changelog file found here: '%s'", err, path)
+		return nil, "", fmt.Errorf("%w: unable to open the changelog file found here: '%s'", err, path)
 	}
-	defer f.Close()
 
-	p.changelog, err = changelog.Parse(f)
+	return buf, path, nil
+}
+
+// parseChangelog parses and validates the changelog contents.
+func (p *Project) parseChangelog(buf []byte, path string) error {
+	if err := p.loadChangelog(buf, path); err != nil {
+		return err
+	}
+
+	if err := p.validateVersions(); err != nil {
+		return fmt.Errorf("%w: in the changelog file found here: '%s'", err, path)
+	}
+
+	return nil
+}
+
+// loadChangelog parses the changelog contents without validating the
+// versions.
+func (p *Project) loadChangelog(buf []byte, path string) error {
+	cl, err := changelog.Parse(bytes.NewReader(buf))
 	if err != nil {
 		return fmt.Errorf("%w: unable to parse the changelog file found here: '%s'", err, path)
 	}
+	p.changelog = cl
+	p.changelogLines = strings.Split(strings.TrimSuffix(string(buf), "\n"), "\n")
 
 	return nil
 }
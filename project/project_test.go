@@ -15,6 +15,7 @@ import (
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 	changelog "github.com/xmidt-org/gokeepachangelog"
+	rbgit "github.com/xmidt-org/release-builder-action/git"
 )
 
 const (
@@ -29,6 +30,33 @@ and this project adheres to [Semantic Versioning](http://semver.org/spec/v2.0.0.
 
 ## [v1.2.3]
 - example
+`
+	malformedChangelog = `# Changelog
+
+## [Unreleased]
+
+## [v1.2]
+- example
+`
+	duplicateChangelog = `# Changelog
+
+## [Unreleased]
+
+## [v1.2.3]
+- example
+
+## [1.2.3]
+- example
+`
+	unorderedChangelog = `# Changelog
+
+## [Unreleased]
+
+## [v1.2.3]
+- example
+
+## [v1.3.0]
+- example
 `
 )
 
@@ -175,6 +203,58 @@ func TestNewProject(t *testing.T) {
 			},
 			expectedErr: errTokenMissing,
 		},
+		{
+			description: "meson archive listed",
+			opts: ProjectOpts{
+				Slug:          "foo/bar",
+				BasePath:      "..",
+				ChangelogFile: "CHANGELOG.md",
+				ArtifactDir:   "artifacts",
+				SHASumFile:    "sha256sum.txt",
+				Archives:      []rbgit.Format{{Name: "zip"}, {Name: "tar.xz", Level: 9}},
+				Meson:         Meson{Archive: "tar.xz"},
+			},
+			dryrun: true,
+		},
+		{
+			description: "meson archive not listed",
+			opts: ProjectOpts{
+				Slug:          "foo/bar",
+				BasePath:      "..",
+				ChangelogFile: "CHANGELOG.md",
+				ArtifactDir:   "artifacts",
+				SHASumFile:    "sha256sum.txt",
+				Meson:         Meson{Archive: "tar.zst"},
+			},
+			dryrun:      true,
+			expectedErr: errMesonArchive,
+		},
+		{
+			description: "duplicate archive",
+			opts: ProjectOpts{
+				Slug:          "foo/bar",
+				BasePath:      "..",
+				ChangelogFile: "CHANGELOG.md",
+				ArtifactDir:   "artifacts",
+				SHASumFile:    "sha256sum.txt",
+				Archives:      []rbgit.Format{{Name: "tar.gz"}, {Name: "tar.gz", Level: 9}},
+			},
+			dryrun:      true,
+			expectedErr: errArchiveDuplicate,
+		},
+		{
+			description: "unknown alias tags",
+			opts: ProjectOpts{
+				Slug:          "foo/bar",
+				BasePath:      "..",
+				ChangelogFile: "CHANGELOG.md",
+				ArtifactDir:   "artifacts",
+				SHASumFile:    "sha256sum.txt",
+				AliasTags:     []string{"major", "patch"},
+			},
+			dryrun:      true,
+			expectedErr: errAliasUnknown,
+		},
 	}
 
 	for _, tc := range tests {
@@ -215,6 +295,21 @@ func TestProcessChangelog(t *testing.T) {
 			changelogFile: "BAD.md",
 			expectedErr:   changelog.ErrParsing,
 		},
+		{
+			description:   "changelog file with a malformed version",
+			changelogFile: "MALFORMED.md",
+			expectedErr:   errVersionInvalid,
+		},
+		{
+			description:   "changelog file with a duplicate version",
+			changelogFile: "DUPLICATE.md",
+			expectedErr:   errVersionDuplicate,
+		},
+		{
+			description:   "changelog file with out of order versions",
+			changelogFile: "UNORDERED.md",
+			expectedErr:   errVersionOrder,
+		},
 	}
 
 	for _, tc := range tests {
@@ -225,6 +320,7 @@ func TestProcessChangelog(t *testing.T) {
 				opts: ProjectOpts{
 					ChangelogFile: tc.changelogFile,
 					BasePath:      ".",
+					TagPrefix:     "v",
 				},
 				fs: &afero.Afero{
 					Fs: afero.NewMemMapFs(),
@@ -235,6 +331,12 @@ func TestProcessChangelog(t *testing.T) {
 			require.NoError(t, err)
 			err = writeFile(p.fs, "BAD.md", badChangelog)
 			require.NoError(t, err)
+			err = writeFile(p.fs, "MALFORMED.md", malformedChangelog)
+			require.NoError(t, err)
+			err = writeFile(p.fs, "DUPLICATE.md", duplicateChangelog)
+			require.NoError(t, err)
+			err = writeFile(p.fs, "UNORDERED.md", unorderedChangelog)
+			require.NoError(t, err)
 
 			err = p.processChangelog()
 			if tc.expectedErr == nil {
@@ -275,25 +377,115 @@ func TestExamineTags(t *testing.T) {
 			},
 		},
 	}
+	multiple := &changelog.Changelog{
+		Releases: []changelog.Release{
+			{
+				Version: "unreleased",
+			},
+			{
+				Version: "0.1.3",
+			},
+			{
+				Version: "0.1.2",
+			},
+			{
+				Version: "0.1.1",
+			},
+		},
+	}
 	tests := []struct {
 		description string
 		cl          *changelog.Changelog
+		tags        []string
+		releaseAll  bool
+		tagTarget   string
+		ref         string
 		release     bool
+		expected    []string
+		commits     []string
 		expectedErr error
+		gitErr      bool
 	}{
 		{
 			description: "success with a release",
 			cl:          release,
 			release:     true,
+			expected:    []string{"0.1.2"},
+		},
+		{
+			description: "success with only the newest release",
+			cl:          multiple,
+			release:     true,
+			expected:    []string{"0.1.3"},
+		},
+		{
+			description: "success with all untagged releases",
+			cl:          multiple,
+			releaseAll:  true,
+			release:     true,
+			expected:    []string{"0.1.3", "0.1.2"},
+		},
+		{
+			description: "success tagging the changelog commit",
+			cl:          release,
+			tagTarget:   TagTargetChangelog,
+			release:     true,
+			expected:    []string{"0.1.2"},
+			commits:     []string{"abc123"},
+		},
+		{
+			description: "success tagging the changelog commits of all releases",
+			cl:          multiple,
+			releaseAll:  true,
+			tagTarget:   TagTargetChangelog,
+			release:     true,
+			expected:    []string{"0.1.3", "0.1.2"},
+			commits:     []string{"def456", "abc123"},
+		},
+		{
+			description: "success tagging the head",
+			cl:          release,
+			tagTarget:   TagTargetHead,
+			release:     true,
+			expected:    []string{"0.1.2"},
+			commits:     []string{""},
+		},
+		{
+			description: "success tagging an explicit ref",
+			cl:          release,
+			tagTarget:   TagTargetChangelog,
+			ref:         "release/0.1.2",
+			release:     true,
+			expected:    []string{"0.1.2"},
+			commits:     []string{"fedcba"},
 		},
 		{
 			description: "success with no release",
 			cl:          norelease,
 		},
+		{
+			description: "failure due to an unknown tag target",
+			cl:          release,
+			tagTarget:   "tail",
+			expectedErr: errTagTargetUnknown,
+		},
+		{
+			description: "failure due to an unknown ref",
+			cl:          release,
+			ref:         "missing",
+			expectedErr: errTest,
+		},
 		{
 			description: "failure due to git call",
 			cl:          release,
 			expectedErr: errTest,
+			gitErr:      true,
+		},
+		{
+			description: "failure due to an older version than the tags",
+			cl:          release,
+			tags:        []string{"0.1.1", "0.2.0"},
+			expectedErr: errVersionNotNewer,
 		},
 	}
 
@@ -302,14 +494,32 @@ func TestExamineTags(t *testing.T) {
 			assert := assert.New(t)
 
 			mockGit := &mockGit{}
-			if tc.expectedErr != nil {
+			if tc.gitErr {
 				mockGit.On("IsTagPresent", mock.Anything).Return(false, tc.expectedErr)
 			} else {
+				tags := tc.tags
+				if tags == nil {
+					tags = []string{"0.1.1", "v1", "other"}
+				}
+				mockGit.On("Tags").Return(tags, nil)
 				mockGit.On("IsTagPresent", "0.1.1").Return(true, nil)
 				mockGit.On("IsTagPresent", "0.1.2").Return(false, nil)
+				mockGit.On("IsTagPresent", "0.1.3").Return(false, nil)
+				mockGit.On("FindVersionCommit", "CHANGELOG.md", "0.1.2").Return("abc123", nil)
+				mockGit.On("FindVersionCommit", "CHANGELOG.md", "0.1.3").Return("def456", nil)
+				mockGit.On("ResolveCommit", "release/0.1.2").Return("fedcba", nil)
+				mockGit.On("ResolveCommit", "missing").Return("", errTest)
 			}
 
 			p := &Project{
+				opts: ProjectOpts{
+					ChangelogFile: "CHANGELOG.md",
+					ArtifactDir:   "artifacts",
+					ReleaseAll:    tc.releaseAll,
+					TagTarget:     tc.tagTarget,
+					Ref:           tc.ref,
+					Log:           func(string, ...interface{}) {},
+				},
 				changelog: tc.cl,
 				git:       mockGit,
 			}
@@ -319,6 +529,15 @@ func TestExamineTags(t *testing.T) {
 				assert.NoError(err)
 				if tc.release {
 					assert.NotNil(p.nextRelease)
+					var got, commits []string
+					for _, r := range p.releases {
+						got = append(got, r.rel.Version)
+						commits = append(commits, r.commit)
+					}
+					assert.Equal(tc.expected, got)
+					if tc.commits != nil {
+						assert.Equal(tc.commits, commits)
+					}
 				} else {
 					assert.Nil(p.nextRelease)
 				}
@@ -332,6 +551,47 @@ func TestExamineTags(t *testing.T) {
 	}
 }
 
+func TestExamineTagsPrefix(t *testing.T) {
+	assert := assert.New(t)
+
+	mockGit := &mockGit{}
+	mockGit.On("IsTagPresent", "v1.2.3").Return(false, nil)
+	mockGit.On("IsTagPresent", "1.2.3").Return(false, nil)
+	mockGit.On("IsTagPresent", "v1.2.2").Return(false, nil)
+	mockGit.On("IsTagPresent", "1.2.2").Return(true, nil)
+	mockGit.On("Tags").Return([]string{"1.2.2"}, nil)
+
+	p := &Project{
+		opts: ProjectOpts{
+			TagPrefix:   "v",
+			ArtifactDir: "artifacts",
+			ReleaseAll:  true,
+		},
+		changelog: &changelog.Changelog{
+			Releases: []changelog.Release{
+				{
+					Version: "Unreleased",
+				},
+				{
+					Version: "1.2.3",
+				},
+				{
+					Version: "v1.2.2",
+				},
+			},
+		},
+		git: mockGit,
+	}
+
+	assert.NoError(p.examineTags())
+	if assert.Len(p.releases, 1) {
+		assert.Equal("v1.2.3", p.releases[0].tag)
+		assert.Equal("1.2.3", p.releases[0].version.String())
+		assert.Equal("artifacts/v1.2.3", p.releases[0].artDir)
+	}
+	mockGit.AssertExpectations(t)
+}
+
 func TestGetReleaseSlug(t *testing.T) {
 	assert := assert.New(t)
 
@@ -351,7 +611,17 @@ func TestGetReleaseSlug(t *testing.T) {
 			},
 		},
 	}
-	p.nextRelease = &p.changelog.Releases[1]
+	assert.Equal("repo-name-0.1.2", p.getReleaseSlug(&p.changelog.Releases[1]))
+}
+
+func TestReleaseName(t *testing.T) {
+	assert := assert.New(t)
+
+	v, err := parseSemver("1.4.0", "")
+	require.NoError(t, err)
+	assert.Equal("v1.4.0 2021-01-02", releaseName(&release{tag: "v1.4.0", version: v}, "2021-01-02"))
 
-	assert.Equal("repo-name-0.1.2", p.getReleaseSlug())
+	v, err = parseSemver("1.4.0-rc.2", "")
+	require.NoError(t, err)
+	assert.Equal("v1.4.0-rc.2 2021-01-02 (prerelease)", releaseName(&release{tag: "v1.4.0-rc.2", version: v}, "2021-01-02"))
 }
@@ -0,0 +1,252 @@
+// SPDX-FileCopyrightText: 2021 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+package project
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	changelog "github.com/xmidt-org/gokeepachangelog"
+)
+
+var (
+	errNotReproducible = errors.New("the archives do not match the published checksums")
+	errTagNotFound     = errors.New("no tagged release was found")
+	errDownload        = errors.New("unable to download the file")
+)
+
+// githubURL is where the published release assets are downloaded from.  The
+// assets of private repositories are downloaded through the githubAPIURL.
+var (
+	githubURL    = "https://github.com"
+	githubAPIURL = "https://api.github.com"
+)
+
+// VerifyReproducible rebuilds the archives of a tagged release and compares
+// their checksums with the published checksum file.  The newest tagged
+// release is used unless a version is specified.  Every difference is
+// reported before failing.
+func (p *Project) VerifyReproducible() error {
+	for _, c := range p.projects() {
+		if err := c.verifyReproducible(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (p *Project) verifyReproducible() error {
+	if p.component != nil {
+		p.opts.Log("Examining the %s component.", p.component.Name)
+	}
+
+	p.opts.Log("Processing the %s file.", p.opts.ChangelogFile)
+	if err := p.processChangelog(); err != nil {
+		return err
+	}
+
+	rel, tag, err := p.findVerifyRelease()
+	if err != nil {
+		return err
+	}
+
+	published, err := p.downloadAsset(tag, p.opts.SHASumFile)
+	if err != nil {
+		return err
+	}
+	sums := parseSha256Sum(published)
+
+	dir, err := p.fs.TempDir("", "verify-reproducible-")
+	if err != nil {
+		return fmt.Errorf("%w: unable to make a temporary directory", err)
+	}
+	defer func() {
+		_ = p.fs.RemoveAll(dir)
+	}()
+
+	v, err := p.parseVersion(rel.Version)
+	if err != nil {
+		return err
+	}
+	extra, err := p.distFiles(tag, v)
+	if err != nil {
+		return err
+	}
+
+	slug := p.getReleaseSlug(rel)
+	var problems []string
+	for _, format := range p.archiveFormats() {
+		p.opts.Log("Rebuilding the %s archive of %s.", format, tag)
+		a, err := p.git.CreateArchive(slug, tag, p.componentPath(), format, dir, extra)
+		if err != nil {
+			return err
+		}
+
+		b, err := sha(p.fs, a.File)
+		if err != nil {
+			return err
+		}
+
+		name := slug + "." + format.Name
+		got := fmt.Sprintf("%x", b)
+		want, found := sums[name]
+		switch {
+		case !found:
+			problems = append(problems, fmt.Sprintf("'%s' is not in the published checksums", name))
+		case want != got:
+			problems = append(problems, fmt.Sprintf("'%s' is %s instead of %s", name, got, want))
+		default:
+			p.opts.Log("The %s archive matches: %s.", name, got)
+		}
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("%w: %s", errNotReproducible, strings.Join(problems, ", "))
+	}
+
+	return nil
+}
+
+// findVerifyRelease returns the changelog release to verify along with the
+// name of its tag.
+func (p *Project) findVerifyRelease() (*changelog.Release, string, error) {
+	var want *semver
+	if p.opts.VerifyVersion != "" {
+		v, err := p.parseVersion(p.opts.VerifyVersion)
+		if err != nil {
+			return nil, "", err
+		}
+		want = &v
+	}
+
+	for i := range p.changelog.Releases {
+		rel := &p.changelog.Releases[i]
+		if isUnreleased(rel.Version) {
+			continue
+		}
+
+		tag, v, err := p.tagName(rel)
+		if err != nil {
+			return nil, "", err
+		}
+		if want != nil && want.Compare(v) != 0 {
+			continue
+		}
+
+		name, err := p.findTag(tag, v)
+		if err != nil {
+			return nil, "", fmt.Errorf("%w: unable to process git repo", err)
+		}
+		if name != "" {
+			return rel, name, nil
+		}
+		if want != nil {
+			return nil, "", fmt.Errorf("%w: '%s' is not tagged", errTagNotFound, tag)
+		}
+	}
+
+	if want != nil {
+		return nil, "", fmt.Errorf("%w: '%s' is not in the changelog", errTagNotFound, p.opts.VerifyVersion)
+	}
+
+	return nil, "", errTagNotFound
+}
+
+// downloadAsset returns the contents of the file published with the release
+// of the tag.  The token is used when provided so private repositories work.
+func (p *Project) downloadAsset(tag, file string) ([]byte, error) {
+	if p.opts.Token == "" {
+		url := fmt.Sprintf("%s/%s/releases/download/%s/%s", githubURL, p.opts.Slug, tag, file)
+		p.opts.Log("Downloading the published checksums from %s.", url)
+		return download(url, "", "")
+	}
+
+	// The release download links don't accept a token, so the asset is found
+	// and downloaded through the API.
+	url := fmt.Sprintf("%s/repos/%s/releases/tags/%s", githubAPIURL, p.opts.Slug, tag)
+	p.opts.Log("Reading the published release from %s.", url)
+	buf, err := download(url, p.opts.Token, "application/vnd.github+json")
+	if err != nil {
+		return nil, err
+	}
+
+	var rel struct {
+		Assets []struct {
+			Name string `json:"name"`
+			URL  string `json:"url"`
+		} `json:"assets"`
+	}
+	if err := json.Unmarshal(buf, &rel); err != nil {
+		return nil, fmt.Errorf("%w: '%s': %s", errDownload, url, err)
+	}
+
+	for _, asset := range rel.Assets {
+		if asset.Name == file {
+			p.opts.Log("Downloading the published checksums from %s.", asset.URL)
+			return download(asset.URL, p.opts.Token, "application/octet-stream")
+		}
+	}
+
+	return nil, fmt.Errorf("%w: '%s' is not published with the release '%s'", errDownload, file, tag)
+}
+
+// download returns the contents of the url.  The token and accepted media
+// type are sent if they aren't empty.
+func download(url, token, accept string) ([]byte, error) {
+	client := &http.Client{
+		Timeout: time.Minute,
+	}
+
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("%w: '%s': %s", errDownload, url, err)
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%w: '%s': %s", errDownload, url, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%w: '%s': %s", errDownload, url, resp.Status)
+	}
+
+	buf, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("%w: '%s': %s", errDownload, url, err)
+	}
+
+	return buf, nil
+}
+
+// parseSha256Sum returns the checksums of a sha256sum file keyed by file name.
+func parseSha256Sum(buf []byte) map[string]string {
+	sums := map[string]string{}
+
+	s := bufio.NewScanner(bytes.NewReader(buf))
+	for s.Scan() {
+		fields := strings.Fields(s.Text())
+		if len(fields) != 2 {
+			continue
+		}
+		// Binary mode entries mark the name with a '*'.
+		sums[strings.TrimPrefix(fields[1], "*")] = strings.ToLower(fields[0])
+	}
+
+	return sums
+}
@@ -0,0 +1,181 @@
+// SPDX-FileCopyrightText: 2021 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+package project
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/xmidt-org/release-builder-action/git"
+)
+
+func TestVerifyReproducible(t *testing.T) {
+	zipSum := fmt.Sprintf("%x", sha256.Sum256([]byte("zip")))
+	tgzSum := fmt.Sprintf("%x", sha256.Sum256([]byte("tgz")))
+
+	tests := []struct {
+		description string
+		version     string
+		tag         string
+		token       string
+		asset       string
+		published   string
+		status      int
+		expectedErr error
+	}{
+		{
+			description: "newest tagged release",
+			tag:         "v1.1.0",
+			published:   zipSum + "  bar-1.1.0.zip\n" + tgzSum + "  bar-1.1.0.tar.gz\nabc  bar.wrap\n",
+		}, {
+			description: "specific version",
+			version:     "1.0.0",
+			tag:         "v1.0.0",
+			published:   zipSum + "  bar-1.0.0.zip\n" + tgzSum + " *bar-1.0.0.tar.gz\n",
+		}, {
+			description: "different archive",
+			tag:         "v1.1.0",
+			published:   zipSum + "  bar-1.1.0.zip\n" + zipSum + "  bar-1.1.0.tar.gz\n",
+			expectedErr: errNotReproducible,
+		}, {
+			description: "missing archive",
+			tag:         "v1.1.0",
+			published:   zipSum + "  bar-1.1.0.zip\n",
+			expectedErr: errNotReproducible,
+		}, {
+			description: "not published",
+			tag:         "v1.1.0",
+			status:      http.StatusNotFound,
+			expectedErr: errDownload,
+		}, {
+			description: "private repository",
+			tag:         "v1.1.0",
+			token:       "token",
+			asset:       "sha256sum.txt",
+			published:   zipSum + "  bar-1.1.0.zip\n" + tgzSum + "  bar-1.1.0.tar.gz\n",
+		}, {
+			description: "private repository without checksums",
+			tag:         "v1.1.0",
+			token:       "token",
+			asset:       "bar-1.1.0.zip",
+			expectedErr: errDownload,
+		}, {
+			description: "private repository not published",
+			tag:         "v1.1.0",
+			token:       "token",
+			status:      http.StatusNotFound,
+			expectedErr: errDownload,
+		}, {
+			description: "version not tagged",
+			version:     "1.2.0",
+			expectedErr: errTagNotFound,
+		}, {
+			description: "version not in the changelog",
+			version:     "0.9.0",
+			expectedErr: errTagNotFound,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			assert := assert.New(t)
+
+			var server *httptest.Server
+			server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if tc.status != 0 {
+					w.WriteHeader(tc.status)
+					return
+				}
+				if tc.token == "" {
+					assert.Equal("/foo/bar/releases/download/"+tc.tag+"/sha256sum.txt", r.URL.Path)
+					assert.Empty(r.Header.Get("Authorization"))
+					fmt.Fprint(w, tc.published)
+					return
+				}
+
+				assert.Equal("Bearer "+tc.token, r.Header.Get("Authorization"))
+				switch r.URL.Path {
+				case "/repos/foo/bar/releases/tags/" + tc.tag:
+					fmt.Fprintf(w, `{"assets": [{"name": %q, "url": %q}]}`, tc.asset, server.URL+"/assets/1")
+				case "/assets/1":
+					assert.Equal("application/octet-stream", r.Header.Get("Accept"))
+					fmt.Fprint(w, tc.published)
+				default:
+					t.Errorf("unexpected request: %s", r.URL.Path)
+					w.WriteHeader(http.StatusNotFound)
+				}
+			}))
+			defer server.Close()
+
+			origURL, origAPIURL := githubURL, githubAPIURL
+			githubURL, githubAPIURL = server.URL, server.URL
+			defer func() {
+				githubURL, githubAPIURL = origURL, origAPIURL
+			}()
+
+			fs := &afero.Afero{Fs: afero.NewMemMapFs()}
+			require.NoError(t, writeFile(fs, "./CHANGELOG.md", `# Changelog
+
+## [Unreleased]
+
+## [v1.2.0]
+- new
+
+## [v1.1.0]
+- old
+
+## [v1.0.0]
+- older
+`))
+
+			mockGit := &mockGit{}
+			mockGit.On("IsTagPresent", "v1.2.0").Return(false, nil).Maybe()
+			mockGit.On("IsTagPresent", "1.2.0").Return(false, nil).Maybe()
+			mockGit.On("IsTagPresent", "v1.1.0").Return(true, nil).Maybe()
+			mockGit.On("IsTagPresent", "v1.0.0").Return(true, nil).Maybe()
+			archive := func(format, data string) {
+				mockGit.On("CreateArchive", mock.Anything, tc.tag, "", git.Format{Name: format}, mock.Anything, mock.Anything).
+					Return(func(slug, _, _ string, format git.Format, dir string, _ []git.ArchiveFile) (git.Archive, error) {
+						file := dir + "/" + slug + "." + format.Name
+						return git.Archive{File: file}, writeFile(fs, file, data)
+					}).Maybe()
+			}
+			archive("zip", "zip")
+			archive("tar.gz", "tgz")
+
+			p := &Project{
+				opts: ProjectOpts{
+					Slug:          "foo/bar",
+					BasePath:      ".",
+					Token:         tc.token,
+					TagPrefix:     "v",
+					ChangelogFile: "CHANGELOG.md",
+					SHASumFile:    "sha256sum.txt",
+					VerifyVersion: tc.version,
+					Log:           func(string, ...interface{}) {},
+				},
+				repoName: "bar",
+				fs:       fs,
+				git:      mockGit,
+			}
+
+			err := p.VerifyReproducible()
+			if tc.expectedErr != nil {
+				assert.True(errors.Is(err, tc.expectedErr),
+					fmt.Errorf("error [%v] doesn't contain error [%v] in its err chain",
+						err, tc.expectedErr),
+				)
+				return
+			}
+			assert.NoError(err)
+		})
+	}
+}
@@ -0,0 +1,244 @@
+// SPDX-FileCopyrightText: 2021 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+package project
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+var (
+	errVersionInvalid = errors.New("the version is not a valid semantic version")
+	errBumpUnknown    = errors.New("the bump kind is unknown")
+)
+
+// semver is a parsed semantic version as defined by https://semver.org/spec/v2.0.0.html
+type semver struct {
+	Major      uint64
+	Minor      uint64
+	Patch      uint64
+	Prerelease []string
+	Build      []string
+}
+
+// parseSemver parses the version after removing the prefix if present.
+func parseSemver(version, prefix string) (semver, error) {
+	var v semver
+
+	s := strings.TrimPrefix(version, prefix)
+
+	if i := strings.Index(s, "+"); i >= 0 {
+		build, err := splitIdentifiers(s[i+1:], false)
+		if err != nil {
+			return v, fmt.Errorf("%w: '%s' build metadata %s", errVersionInvalid, version, err)
+		}
+		v.Build = build
+		s = s[:i]
+	}
+
+	if i := strings.Index(s, "-"); i >= 0 {
+		pre, err := splitIdentifiers(s[i+1:], true)
+		if err != nil {
+			return v, fmt.Errorf("%w: '%s' prerelease %s", errVersionInvalid, version, err)
+		}
+		v.Prerelease = pre
+		s = s[:i]
+	}
+
+	parts := strings.Split(s, ".")
+	if len(parts) != 3 {
+		return v, fmt.Errorf("%w: '%s' must have the form major.minor.patch", errVersionInvalid, version)
+	}
+
+	nums := make([]uint64, 3)
+	for i, part := range parts {
+		if !isNumeric(part) || (len(part) > 1 && part[0] == '0') {
+			return v, fmt.Errorf("%w: '%s' has an invalid number '%s'", errVersionInvalid, version, part)
+		}
+		n, err := strconv.ParseUint(part, 10, 64)
+		if err != nil {
+			return v, fmt.Errorf("%w: '%s' has an invalid number '%s'", errVersionInvalid, version, part)
+		}
+		nums[i] = n
+	}
+	v.Major, v.Minor, v.Patch = nums[0], nums[1], nums[2]
+
+	return v, nil
+}
+
+func splitIdentifiers(s string, prerelease bool) ([]string, error) {
+	ids := strings.Split(s, ".")
+	for _, id := range ids {
+		if id == "" {
+			return nil, errors.New("has an empty identifier")
+		}
+		for _, c := range id {
+			if !(c == '-' || ('0' <= c && c <= '9') || ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z')) {
+				return nil, fmt.Errorf("identifier '%s' has an invalid character", id)
+			}
+		}
+		if prerelease && isNumeric(id) && len(id) > 1 && id[0] == '0' {
+			return nil, fmt.Errorf("identifier '%s' has a leading zero", id)
+		}
+	}
+
+	return ids, nil
+}
+
+func isNumeric(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, c := range s {
+		if c < '0' || '9' < c {
+			return false
+		}
+	}
+	return true
+}
+
+// IsPrerelease returns true if the version has prerelease identifiers.
+func (v semver) IsPrerelease() bool {
+	return len(v.Prerelease) > 0
+}
+
+// Core returns the major.minor.patch portion of the version.
+func (v semver) Core() string {
+	return fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+}
+
+// String returns the canonical form of the version without any prefix.
+func (v semver) String() string {
+	s := v.Core()
+	if len(v.Prerelease) > 0 {
+		s += "-" + strings.Join(v.Prerelease, ".")
+	}
+	if len(v.Build) > 0 {
+		s += "+" + strings.Join(v.Build, ".")
+	}
+	return s
+}
+
+// Compare returns -1, 0 or 1 if v is less than, equal to or greater than o
+// using the semantic version precedence rules.  Build metadata is ignored.
+func (v semver) Compare(o semver) int {
+	if c := compareUint(v.Major, o.Major); c != 0 {
+		return c
+	}
+	if c := compareUint(v.Minor, o.Minor); c != 0 {
+		return c
+	}
+	if c := compareUint(v.Patch, o.Patch); c != 0 {
+		return c
+	}
+
+	// A version without a prerelease has a higher precedence.
+	switch {
+	case len(v.Prerelease) == 0 && len(o.Prerelease) == 0:
+		return 0
+	case len(v.Prerelease) == 0:
+		return 1
+	case len(o.Prerelease) == 0:
+		return -1
+	}
+
+	for i := 0; i < len(v.Prerelease) && i < len(o.Prerelease); i++ {
+		if c := compareIdentifier(v.Prerelease[i], o.Prerelease[i]); c != 0 {
+			return c
+		}
+	}
+
+	return compareUint(uint64(len(v.Prerelease)), uint64(len(o.Prerelease)))
+}
+
+func compareIdentifier(a, b string) int {
+	an, bn := isNumeric(a), isNumeric(b)
+	switch {
+	case an && bn:
+		x, _ := strconv.ParseUint(a, 10, 64)
+		y, _ := strconv.ParseUint(b, 10, 64)
+		return compareUint(x, y)
+	case an:
+		return -1
+	case bn:
+		return 1
+	}
+
+	return strings.Compare(a, b)
+}
+
+func compareUint(a, b uint64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// bump returns the next version of the specified kind: major, minor, patch or
+// prerelease.  Bumping a prerelease to a release of the same line finishes the
+// prerelease, so 1.3.0-rc.2 bumped by minor is 1.3.0.  A prerelease bump of a
+// release starts a new patch prerelease using the identifier (1.2.4-rc.1).
+func (v semver) bump(kind, preid string) (semver, error) {
+	next := semver{
+		Major: v.Major,
+		Minor: v.Minor,
+		Patch: v.Patch,
+	}
+
+	switch kind {
+	case "major":
+		if !v.IsPrerelease() || v.Minor != 0 || v.Patch != 0 {
+			next.Major++
+			next.Minor = 0
+			next.Patch = 0
+		}
+	case "minor":
+		if !v.IsPrerelease() || v.Patch != 0 {
+			next.Minor++
+			next.Patch = 0
+		}
+	case "patch":
+		if !v.IsPrerelease() {
+			next.Patch++
+		}
+	case "prerelease":
+		if !v.IsPrerelease() {
+			next.Patch++
+			next.Prerelease = []string{preid, "1"}
+			break
+		}
+
+		next.Prerelease = append([]string{}, v.Prerelease...)
+		last := len(next.Prerelease) - 1
+		if n, err := strconv.ParseUint(next.Prerelease[last], 10, 64); err == nil {
+			next.Prerelease[last] = strconv.FormatUint(n+1, 10)
+		} else {
+			next.Prerelease = append(next.Prerelease, "1")
+		}
+	default:
+		return next, fmt.Errorf("%w: '%s'", errBumpUnknown, kind)
+	}
+
+	return next, nil
+}
+
+// parseVersion parses a changelog version, which may be written with the tag
+// prefix, without it or, for a prefix with a path like 'sub/dir/v', with only
+// the part after the path.
+func (p *Project) parseVersion(version string) (semver, error) {
+	v, err := parseSemver(version, p.opts.TagPrefix)
+	if err == nil {
+		return v, nil
+	}
+
+	if i := strings.LastIndex(p.opts.TagPrefix, "/"); i >= 0 {
+		return parseSemver(version, p.opts.TagPrefix[i+1:])
+	}
+
+	return v, err
+}
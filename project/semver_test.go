@@ -0,0 +1,148 @@
+// SPDX-FileCopyrightText: 2021 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+package project
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseSemver(t *testing.T) {
+	tests := []struct {
+		description string
+		version     string
+		prefix      string
+		expected    string
+		expectedErr error
+	}{
+		{
+			description: "simple",
+			version:     "1.2.3",
+			expected:    "1.2.3",
+		},
+		{
+			description: "with prefix",
+			version:     "v1.2.3",
+			prefix:      "v",
+			expected:    "1.2.3",
+		},
+		{
+			description: "prerelease and build",
+			version:     "1.2.3-rc.1+build.5",
+			expected:    "1.2.3-rc.1+build.5",
+		},
+		{
+			description: "prefix not stripped",
+			version:     "v1.2.3",
+			expectedErr: errVersionInvalid,
+		},
+		{
+			description: "missing patch",
+			version:     "1.2",
+			expectedErr: errVersionInvalid,
+		},
+		{
+			description: "leading zero",
+			version:     "1.02.3",
+			expectedErr: errVersionInvalid,
+		},
+		{
+			description: "leading zero prerelease",
+			version:     "1.2.3-01",
+			expectedErr: errVersionInvalid,
+		},
+		{
+			description: "empty prerelease identifier",
+			version:     "1.2.3-rc..1",
+			expectedErr: errVersionInvalid,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			assert := assert.New(t)
+
+			v, err := parseSemver(tc.version, tc.prefix)
+			if tc.expectedErr == nil {
+				assert.NoError(err)
+				assert.Equal(tc.expected, v.String())
+				return
+			}
+			assert.True(errors.Is(err, tc.expectedErr),
+				fmt.Errorf("error [%v] doesn't contain error [%v] in its err chain",
+					err, tc.expectedErr),
+			)
+		})
+	}
+}
+
+func TestSemverCompare(t *testing.T) {
+	// Ordered list from the semver.org specification.
+	ordered := []string{
+		"1.0.0-alpha",
+		"1.0.0-alpha.1",
+		"1.0.0-alpha.beta",
+		"1.0.0-beta",
+		"1.0.0-beta.2",
+		"1.0.0-beta.11",
+		"1.0.0-rc.1",
+		"1.0.0",
+		"1.0.1",
+		"1.1.0",
+		"2.0.0",
+	}
+
+	for i := 0; i < len(ordered)-1; i++ {
+		a, err := parseSemver(ordered[i], "")
+		assert.NoError(t, err)
+		b, err := parseSemver(ordered[i+1], "")
+		assert.NoError(t, err)
+
+		assert.Equal(t, -1, a.Compare(b), "%s < %s", ordered[i], ordered[i+1])
+		assert.Equal(t, 1, b.Compare(a), "%s > %s", ordered[i+1], ordered[i])
+		assert.Equal(t, 0, a.Compare(a))
+	}
+}
+
+func TestSemverBump(t *testing.T) {
+	tests := []struct {
+		version     string
+		kind        string
+		expected    string
+		expectedErr error
+	}{
+		{version: "1.2.3", kind: "major", expected: "2.0.0"},
+		{version: "1.2.3", kind: "minor", expected: "1.3.0"},
+		{version: "1.2.3", kind: "patch", expected: "1.2.4"},
+		{version: "1.2.3", kind: "prerelease", expected: "1.2.4-rc.1"},
+		{version: "2.0.0-rc.1", kind: "major", expected: "2.0.0"},
+		{version: "1.3.0-rc.1", kind: "minor", expected: "1.3.0"},
+		{version: "1.2.4-rc.1", kind: "patch", expected: "1.2.4"},
+		{version: "1.2.4-rc.1", kind: "prerelease", expected: "1.2.4-rc.2"},
+		{version: "1.2.4-beta", kind: "prerelease", expected: "1.2.4-beta.1"},
+		{version: "1.2.3", kind: "huge", expectedErr: errBumpUnknown},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.version+" "+tc.kind, func(t *testing.T) {
+			assert := assert.New(t)
+
+			v, err := parseSemver(tc.version, "")
+			assert.NoError(err)
+
+			next, err := v.bump(tc.kind, "rc")
+			if tc.expectedErr == nil {
+				assert.NoError(err)
+				assert.Equal(tc.expected, next.String())
+				return
+			}
+			assert.True(errors.Is(err, tc.expectedErr),
+				fmt.Errorf("error [%v] doesn't contain error [%v] in its err chain",
+					err, tc.expectedErr),
+			)
+		})
+	}
+}
@@ -0,0 +1,148 @@
+// SPDX-FileCopyrightText: 2021 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+package project
+
+import (
+	"strings"
+)
+
+const (
+	bumpNone = iota
+	bumpPatch
+	bumpMinor
+	bumpMajor
+)
+
+var (
+	bumpNames = []string{"none", "patch", "minor", "major"}
+
+	// breakingMarkers start the Changed entries that are breaking changes,
+	// after the list marker and in lower case.
+	breakingMarkers = []string{
+		"!",
+		"breaking:",
+		"breaking change:",
+		"**breaking**",
+		"**breaking:**",
+		"**breaking change**",
+		"**breaking change:**",
+	}
+)
+
+// suggestion is the smallest next release the unreleased changes need.
+type suggestion struct {
+	bump    int
+	version semver
+}
+
+// requiredBump returns the smallest bump the changes in a section need.
+// Removed or breaking Changed entries need a major bump, Added or Deprecated
+// entries need a minor bump and anything else needs a patch bump.  With
+// initialDev set, breaking changes only need a minor bump while the major
+// version is 0.
+func requiredBump(s section, base semver, initialDev bool) int {
+	bump := bumpNone
+	for _, sub := range s.subsections {
+		if len(sub.items) == 0 {
+			continue
+		}
+
+		need := bumpPatch
+		switch sub.name {
+		case "Removed":
+			need = bumpMajor
+		case "Changed":
+			for _, item := range sub.items {
+				if isBreaking(item) {
+					need = bumpMajor
+				}
+			}
+		case "Added", "Deprecated":
+			need = bumpMinor
+		}
+
+		if need > bump {
+			bump = need
+		}
+	}
+
+	if initialDev && bump == bumpMajor && base.Major == 0 {
+		bump = bumpMinor
+	}
+
+	return bump
+}
+
+// isBreaking returns true if the changelog entry starts with a breaking
+// change marker, like '- BREAKING: ...', '- **Breaking** ...' or '- !...'.
+// Mentions elsewhere, like 'fixes the breaking build', don't count.
+func isBreaking(item string) bool {
+	if len(item) > 1 && strings.ContainsRune("-*+", rune(item[0])) && item[1] == ' ' {
+		item = strings.TrimSpace(item[2:])
+	}
+	item = strings.ToLower(item)
+
+	for _, marker := range breakingMarkers {
+		if strings.HasPrefix(item, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+// bumpBetween returns the kind of bump made going from one version to the
+// next based on the major.minor.patch portion of the versions.
+func bumpBetween(from, to semver) int {
+	switch {
+	case from.Major != to.Major:
+		return bumpMajor
+	case from.Minor != to.Minor:
+		return bumpMinor
+	case from.Patch != to.Patch:
+		return bumpPatch
+	}
+	return bumpNone
+}
+
+// suggestNext determines the smallest next release based on the unreleased
+// changes and the newest release in the changelog.
+func (p *Project) suggestNext() error {
+	p.suggested = nil
+
+	var latest semver
+	for _, rel := range p.changelog.Releases {
+		if isUnreleased(rel.Version) {
+			continue
+		}
+
+		v, err := p.parseVersion(rel.Version)
+		if err != nil {
+			return err
+		}
+		latest = v
+		break
+	}
+
+	for _, s := range scanChangelog(p.changelogLines).sections {
+		if !isUnreleased(s.label) {
+			continue
+		}
+
+		bump := requiredBump(s, latest, p.opts.InitialDevelopment)
+		if bump == bumpNone {
+			return nil
+		}
+
+		next, err := latest.bump(bumpNames[bump], "")
+		if err != nil {
+			return err
+		}
+		p.suggested = &suggestion{
+			bump:    bump,
+			version: next,
+		}
+		return nil
+	}
+
+	return nil
+}
@@ -0,0 +1,153 @@
+// SPDX-FileCopyrightText: 2021 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+package project
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	changelog "github.com/xmidt-org/gokeepachangelog"
+)
+
+func TestSuggestNext(t *testing.T) {
+	tests := []struct {
+		description string
+		latest      string
+		unreleased  string
+		initialDev  bool
+		expected    string
+	}{
+		{
+			description: "nothing to release",
+			latest:      "v1.2.3",
+		},
+		{
+			description: "fixes are a patch",
+			latest:      "v1.2.3",
+			unreleased:  "### Fixed\n- bug\n### Security\n- hole",
+			expected:    "v1.2.4",
+		},
+		{
+			description: "changes are a patch",
+			latest:      "v1.2.3",
+			unreleased:  "### Changed\n- tweak",
+			expected:    "v1.2.4",
+		},
+		{
+			description: "additions are a minor",
+			latest:      "v1.2.3",
+			unreleased:  "### Fixed\n- bug\n### Added\n- feature",
+			expected:    "v1.3.0",
+		},
+		{
+			description: "deprecations are a minor",
+			latest:      "v1.2.3",
+			unreleased:  "### Deprecated\n- feature",
+			expected:    "v1.3.0",
+		},
+		{
+			description: "removals are a major",
+			latest:      "v1.2.3",
+			unreleased:  "### Removed\n- feature",
+			expected:    "v2.0.0",
+		},
+		{
+			description: "breaking changes are a major",
+			latest:      "v1.2.3",
+			unreleased:  "### Changed\n- **Breaking** new API",
+			expected:    "v2.0.0",
+		},
+		{
+			description: "breaking marker",
+			latest:      "v1.2.3",
+			unreleased:  "### Changed\n- BREAKING: new API",
+			expected:    "v2.0.0",
+		},
+		{
+			description: "breaking change marker",
+			latest:      "v1.2.3",
+			unreleased:  "### Changed\n* **Breaking change:** new API",
+			expected:    "v2.0.0",
+		},
+		{
+			description: "bang marker",
+			latest:      "v1.2.3",
+			unreleased:  "### Changed\n- tweak\n- !new API",
+			expected:    "v2.0.0",
+		},
+		{
+			description: "non-breaking changes are a patch",
+			latest:      "v1.2.3",
+			unreleased:  "### Changed\n- non-breaking API tweak\n- not breaking anything",
+			expected:    "v1.2.4",
+		},
+		{
+			description: "mentions of breaking are a patch",
+			latest:      "v1.2.3",
+			unreleased:  "### Changed\n- fixes the breaking build\n- **Faster** breaking of lines",
+			expected:    "v1.2.4",
+		},
+		{
+			description: "breaking changes before 1.0.0 are a major",
+			latest:      "v0.2.3",
+			unreleased:  "### Removed\n- feature",
+			expected:    "v1.0.0",
+		},
+		{
+			description: "breaking changes during initial development are a minor",
+			latest:      "v0.2.3",
+			unreleased:  "### Removed\n- feature",
+			initialDev:  true,
+			expected:    "v0.3.0",
+		},
+		{
+			description: "initial development ends at 1.0.0",
+			latest:      "v1.2.3",
+			unreleased:  "### Removed\n- feature",
+			initialDev:  true,
+			expected:    "v2.0.0",
+		},
+		{
+			description: "empty sections are ignored",
+			latest:      "v1.2.3",
+			unreleased:  "### Removed\n### Fixed\n- bug",
+			expected:    "v1.2.4",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			assert := assert.New(t)
+
+			contents := "# Changelog\n\n## [Unreleased]\n" + tc.unreleased + "\n\n## [" + tc.latest + "]\n- example\n"
+			p := &Project{
+				opts: ProjectOpts{
+					TagPrefix:          "v",
+					InitialDevelopment: tc.initialDev,
+				},
+				changelog: &changelog.Changelog{
+					Releases: []changelog.Release{
+						{
+							Version: "Unreleased",
+						},
+						{
+							Version: tc.latest,
+						},
+					},
+				},
+				changelogLines: strings.Split(contents, "\n"),
+			}
+
+			require.NoError(t, p.suggestNext())
+			if tc.expected == "" {
+				assert.Nil(p.suggested)
+				return
+			}
+			if assert.NotNil(p.suggested) {
+				assert.Equal(tc.expected, "v"+p.suggested.version.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,251 @@
+// SPDX-FileCopyrightText: 2021 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+package project
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+
+	gh "github.com/sethvargo/go-githubactions"
+	"github.com/xmidt-org/release-builder-action/git"
+)
+
+var (
+	errTagSignature = errors.New("the release tags are not signed correctly")
+)
+
+// verifyTags ensures every tagged changelog release is signed by one of the
+// allowed signing keys.  Every bad tag is reported before failing.
+func (p *Project) verifyTags() error {
+	if p.opts.Tagging.AllowedSigners == "" {
+		return nil
+	}
+
+	p.opts.Log("Verifying the release tag signatures.")
+	var bad int
+	for i := range p.changelog.Releases {
+		rel := &p.changelog.Releases[i]
+		if isUnreleased(rel.Version) {
+			continue
+		}
+
+		tag, v, err := p.tagName(rel)
+		if err != nil {
+			return err
+		}
+
+		name, err := p.findTag(tag, v)
+		if err != nil {
+			return fmt.Errorf("%w: unable to process git repo", err)
+		}
+		if name == "" {
+			continue
+		}
+
+		key, err := p.git.VerifyTag(name)
+		if errors.Is(err, git.ErrTagNotFound) {
+			// Only the upstream repository has the tag and its object isn't
+			// available to check.
+			p.opts.Log("Tag %s is only present upstream, its signature isn't checked.", name)
+			continue
+		}
+		if err != nil {
+			p.opts.Log("%s", err)
+			bad++
+			continue
+		}
+		p.opts.Log("Tag %s is signed by %s.", name, key)
+	}
+
+	if bad > 0 {
+		return fmt.Errorf("%w: %d release tag(s) are not signed by an allowed key", errTagSignature, bad)
+	}
+
+	return nil
+}
+
+// examineRemote reads the tags of the upstream repo since a shallow clone,
+// like the actions/checkout default, often has none of the tags and a
+// release that was tagged long ago would look new.
+func (p *Project) examineRemote() error {
+	shallow, err := p.git.IsShallow()
+	if err != nil {
+		return fmt.Errorf("%w: unable to process git repo", err)
+	}
+
+	if p.opts.LocalOnly {
+		if shallow {
+			p.opts.Log("Warning: the repository is a shallow clone, tags may be missing.")
+		}
+		return nil
+	}
+
+	p.opts.Log("Reading the upstream repository tags.")
+	tags, err := p.git.RemoteTags(p.opts.Token)
+	if err != nil {
+		if shallow {
+			return fmt.Errorf("%w: %s", errShallowClone, err)
+		}
+		if p.opts.Token == "" {
+			p.opts.Log("Warning: unable to read the upstream tags, only the local tags are used: %s", err)
+			return nil
+		}
+		return fmt.Errorf("%w: unable to read the upstream tags", err)
+	}
+	if shallow {
+		p.opts.Log("The repository is a shallow clone, the upstream tags are used.")
+	}
+	p.remoteTags = tags
+
+	return nil
+}
+
+// allTags returns the local tags along with the upstream tags that are not
+// present locally.
+func (p *Project) allTags() ([]string, error) {
+	tags, err := p.git.Tags()
+	if err != nil {
+		return nil, fmt.Errorf("%w: unable to process git repo", err)
+	}
+
+	local := map[string]bool{}
+	for _, tag := range tags {
+		local[tag] = true
+	}
+	for tag := range p.remoteTags {
+		if !local[tag] {
+			tags = append(tags, tag)
+		}
+	}
+	sort.Strings(tags)
+
+	return tags, nil
+}
+
+// The states of a changelog release in the tag report.
+const (
+	tagStatusTagged   = "tagged"
+	tagStatusUntagged = "untagged"
+	tagStatusMismatch = "mismatch"
+	tagStatusUnknown  = "unknown"
+)
+
+// tagReport is the state of the tag of a changelog release.
+type tagReport struct {
+	Component string `json:"component,omitempty"`
+	Version   string `json:"version"`
+	Tag       string `json:"tag,omitempty"`
+	Status    string `json:"status"`
+	Commit    string `json:"commit,omitempty"`
+	Annotated bool   `json:"annotated"`
+	Tagger    string `json:"tagger,omitempty"`
+}
+
+// checkTagCommits reports the tag of every changelog release and warns about
+// the tags that point at a commit where the changelog doesn't list the
+// release yet, which happens after a force push or a tag made by hand.
+func (p *Project) checkTagCommits() error {
+	p.tagReport = nil
+
+	var mismatched int
+	for i := range p.changelog.Releases {
+		rel := &p.changelog.Releases[i]
+		if isUnreleased(rel.Version) {
+			continue
+		}
+
+		tag, v, err := p.tagName(rel)
+		if err != nil {
+			return err
+		}
+
+		report, err := p.inspectRelease(rel.Version, tag, v)
+		if err != nil {
+			return err
+		}
+		if p.component != nil {
+			report.Component = p.component.Name
+		}
+
+		switch report.Status {
+		case tagStatusMismatch:
+			mismatched++
+			p.opts.Log("Warning: tag %s points at %s, where %s doesn't list %s.",
+				report.Tag, report.Commit, p.opts.ChangelogFile, rel.Version)
+		case tagStatusUnknown:
+			p.opts.Log("Warning: the commit %s of tag %s isn't available locally, it can't be checked.",
+				report.Commit, report.Tag)
+		}
+		p.tagReport = append(p.tagReport, report)
+	}
+
+	if mismatched > 0 {
+		p.opts.Log("Warning: %d release tag(s) point at the wrong commit.", mismatched)
+	}
+
+	return nil
+}
+
+// outputTagReport sets the tag report output of every component.
+func (p *Project) outputTagReport() error {
+	reports := []tagReport{}
+	for _, c := range p.projects() {
+		reports = append(reports, c.tagReport...)
+	}
+
+	buf, err := json.Marshal(reports)
+	if err != nil {
+		return fmt.Errorf("%w: unable to encode the tag report output", err)
+	}
+
+	gh.SetOutput("tag-report", string(buf))
+	return nil
+}
+
+// inspectRelease returns the state of the tag of the release.  Tags that are
+// only in the upstream repo are described by the upstream refs.
+func (p *Project) inspectRelease(version, tag string, v semver) (tagReport, error) {
+	report := tagReport{
+		Version: version,
+		Status:  tagStatusUntagged,
+	}
+
+	name, err := p.findTag(tag, v)
+	if err != nil {
+		return report, fmt.Errorf("%w: unable to process git repo", err)
+	}
+	if name == "" {
+		return report, nil
+	}
+	report.Tag = name
+
+	info, err := p.git.InspectTag(name)
+	switch {
+	case err == nil:
+		report.Commit = info.Commit
+		report.Annotated = info.Annotated
+		report.Tagger = info.Tagger
+	case errors.Is(err, git.ErrTagNotFound):
+		ref := p.remoteTags[name]
+		report.Commit = ref.Commit
+		report.Annotated = ref.Object != ref.Commit
+	default:
+		return report, fmt.Errorf("%w: unable to process git repo", err)
+	}
+
+	listed, err := p.git.HasVersion(report.Commit, p.opts.ChangelogFile, version)
+	switch {
+	case err == nil && listed:
+		report.Status = tagStatusTagged
+	case err == nil:
+		report.Status = tagStatusMismatch
+	case errors.Is(err, git.ErrCommitMissing):
+		report.Status = tagStatusUnknown
+	default:
+		return report, fmt.Errorf("%w: unable to check the tag '%s'", err, name)
+	}
+
+	return report, nil
+}
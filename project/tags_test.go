@@ -0,0 +1,311 @@
+// SPDX-FileCopyrightText: 2021 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+package project
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	changelog "github.com/xmidt-org/gokeepachangelog"
+	"github.com/xmidt-org/release-builder-action/git"
+)
+
+func TestVerifyTags(t *testing.T) {
+	tests := []struct {
+		description string
+		allowed     string
+		bad         bool
+		remoteOnly  bool
+		expectedErr error
+	}{
+		{
+			description: "not configured",
+		}, {
+			description: "all signed",
+			allowed:     "ssh-ed25519 AAAA",
+		}, {
+			description: "bad signature",
+			allowed:     "ssh-ed25519 AAAA",
+			bad:         true,
+			expectedErr: errTagSignature,
+		}, {
+			description: "upstream only tag",
+			allowed:     "ssh-ed25519 AAAA",
+			remoteOnly:  true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			assert := assert.New(t)
+
+			releases := []changelog.Release{
+				{Version: "Unreleased"},
+				{Version: "1.2.3"},
+				{Version: "1.2.2"},
+				{Version: "v1.2.1"},
+			}
+			remote := map[string]git.TagRef{}
+
+			mockGit := &mockGit{}
+			if tc.remoteOnly {
+				releases = append(releases, changelog.Release{Version: "1.2.0"})
+				remote["v1.2.0"] = git.TagRef{Object: "abc", Commit: "def"}
+				mockGit.On("VerifyTag", "v1.2.0").Return("", fmt.Errorf("%w: 'v1.2.0'", git.ErrTagNotFound))
+			}
+			if tc.allowed != "" {
+				mockGit.On("IsTagPresent", "v1.2.3").Return(false, nil)
+				mockGit.On("IsTagPresent", "1.2.3").Return(false, nil)
+				mockGit.On("IsTagPresent", "v1.2.2").Return(false, nil)
+				mockGit.On("IsTagPresent", "1.2.2").Return(true, nil)
+				mockGit.On("IsTagPresent", "v1.2.1").Return(true, nil)
+				mockGit.On("VerifyTag", "1.2.2").Return("SSH key SHA256:abc", nil)
+				if tc.bad {
+					mockGit.On("VerifyTag", "v1.2.1").Return("", errors.New("invalid"))
+				} else {
+					mockGit.On("VerifyTag", "v1.2.1").Return("SSH key SHA256:abc", nil)
+				}
+			}
+
+			var logged []string
+			p := &Project{
+				opts: ProjectOpts{
+					TagPrefix: "v",
+					Tagging: git.Options{
+						AllowedSigners: tc.allowed,
+					},
+					Log: func(format string, v ...interface{}) {
+						logged = append(logged, fmt.Sprintf(format, v...))
+					},
+				},
+				changelog: &changelog.Changelog{
+					Releases: releases,
+				},
+				remoteTags: remote,
+				git:        mockGit,
+			}
+
+			err := p.verifyTags()
+			mockGit.AssertExpectations(t)
+			if tc.remoteOnly {
+				assert.Contains(logged, "Tag v1.2.0 is only present upstream, its signature isn't checked.")
+			}
+			if tc.expectedErr != nil {
+				assert.True(errors.Is(err, tc.expectedErr),
+					fmt.Errorf("error [%v] doesn't contain error [%v] in its err chain",
+						err, tc.expectedErr),
+				)
+				return
+			}
+			assert.NoError(err)
+		})
+	}
+}
+
+func TestExamineRemote(t *testing.T) {
+	unknownErr := errors.New("unknown")
+	remote := map[string]git.TagRef{
+		"v1.2.3": {Object: "abc", Commit: "def"},
+	}
+
+	tests := []struct {
+		description string
+		localOnly   bool
+		token       string
+		shallow     bool
+		shallowErr  error
+		tags        map[string]git.TagRef
+		tagsErr     error
+		expected    map[string]git.TagRef
+		expectedErr error
+	}{
+		{
+			description: "remote tags",
+			token:       "token",
+			tags:        remote,
+			expected:    remote,
+		}, {
+			description: "shallow clone uses the remote tags",
+			token:       "token",
+			shallow:     true,
+			tags:        remote,
+			expected:    remote,
+		}, {
+			description: "local only",
+			localOnly:   true,
+			shallow:     true,
+		}, {
+			description: "public repo without access",
+			tagsErr:     unknownErr,
+		}, {
+			description: "shallow clone without access",
+			shallow:     true,
+			tagsErr:     unknownErr,
+			expectedErr: errShallowClone,
+		}, {
+			description: "remote error",
+			token:       "token",
+			tagsErr:     unknownErr,
+			expectedErr: unknownErr,
+		}, {
+			description: "shallow error",
+			shallowErr:  unknownErr,
+			expectedErr: unknownErr,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			assert := assert.New(t)
+
+			mockGit := &mockGit{}
+			mockGit.On("IsShallow").Return(tc.shallow, tc.shallowErr)
+			if !tc.localOnly && tc.shallowErr == nil {
+				mockGit.On("RemoteTags", tc.token).Return(tc.tags, tc.tagsErr)
+			}
+
+			p := &Project{
+				opts: ProjectOpts{
+					Token:     tc.token,
+					LocalOnly: tc.localOnly,
+					Log:       func(string, ...interface{}) {},
+				},
+				git: mockGit,
+			}
+
+			err := p.examineRemote()
+			mockGit.AssertExpectations(t)
+			if tc.expectedErr != nil {
+				assert.True(errors.Is(err, tc.expectedErr),
+					fmt.Errorf("error [%v] doesn't contain error [%v] in its err chain",
+						err, tc.expectedErr),
+				)
+				return
+			}
+			assert.NoError(err)
+			assert.Equal(tc.expected, p.remoteTags)
+		})
+	}
+}
+
+func TestAllTags(t *testing.T) {
+	assert := assert.New(t)
+
+	mockGit := &mockGit{}
+	mockGit.On("Tags").Return([]string{"v1.2.2", "v1.2.3"}, nil)
+
+	p := &Project{
+		remoteTags: map[string]git.TagRef{
+			"v1.2.3": {Object: "abc", Commit: "abc"},
+			"v1.2.4": {Object: "def", Commit: "def"},
+		},
+		git: mockGit,
+	}
+
+	tags, err := p.allTags()
+	assert.NoError(err)
+	assert.Equal([]string{"v1.2.2", "v1.2.3", "v1.2.4"}, tags)
+}
+
+func TestCheckTagCommits(t *testing.T) {
+	errTest := errors.New("test error")
+
+	tests := []struct {
+		description string
+		remote      map[string]git.TagRef
+		listed      bool
+		hasErr      error
+		expected    []tagReport
+		expectedLog string
+		expectedErr error
+	}{
+		{
+			description: "consistent",
+			listed:      true,
+			expected: []tagReport{
+				{Version: "1.2.3", Status: tagStatusUntagged},
+				{Version: "v1.2.2", Tag: "v1.2.2", Status: tagStatusTagged, Commit: "abc", Annotated: true, Tagger: "Test <test@example.com>"},
+			},
+		}, {
+			description: "tagged before the changelog",
+			expected: []tagReport{
+				{Version: "1.2.3", Status: tagStatusUntagged},
+				{Version: "v1.2.2", Tag: "v1.2.2", Status: tagStatusMismatch, Commit: "abc", Annotated: true, Tagger: "Test <test@example.com>"},
+			},
+			expectedLog: "Warning: tag v1.2.2 points at abc, where CHANGELOG.md doesn't list v1.2.2.",
+		}, {
+			description: "only upstream",
+			remote:      map[string]git.TagRef{"v1.2.2": {Object: "def", Commit: "abc"}},
+			hasErr:      git.ErrCommitMissing,
+			expected: []tagReport{
+				{Version: "1.2.3", Status: tagStatusUntagged},
+				{Version: "v1.2.2", Tag: "v1.2.2", Status: tagStatusUnknown, Commit: "abc", Annotated: true},
+			},
+			expectedLog: "Warning: the commit abc of tag v1.2.2 isn't available locally, it can't be checked.",
+		}, {
+			description: "git error",
+			hasErr:      errTest,
+			expectedErr: errTest,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			assert := assert.New(t)
+
+			mockGit := &mockGit{}
+			mockGit.On("IsTagPresent", "v1.2.3").Return(false, nil)
+			mockGit.On("IsTagPresent", "1.2.3").Return(false, nil)
+			if tc.remote != nil {
+				mockGit.On("InspectTag", "v1.2.2").Return(nil, git.ErrTagNotFound)
+			} else {
+				mockGit.On("IsTagPresent", "v1.2.2").Return(true, nil)
+				mockGit.On("InspectTag", "v1.2.2").Return(git.TagInfo{
+					Commit:    "abc",
+					Annotated: true,
+					Tagger:    "Test <test@example.com>",
+				}, nil)
+			}
+			mockGit.On("HasVersion", "abc", "CHANGELOG.md", "v1.2.2").Return(tc.listed, tc.hasErr)
+
+			var logs []string
+			p := &Project{
+				opts: ProjectOpts{
+					TagPrefix:     "v",
+					ChangelogFile: "CHANGELOG.md",
+					Log: func(format string, v ...interface{}) {
+						logs = append(logs, fmt.Sprintf(format, v...))
+					},
+				},
+				changelog: &changelog.Changelog{
+					Releases: []changelog.Release{
+						{Version: "Unreleased"},
+						{Version: "1.2.3"},
+						{Version: "v1.2.2"},
+					},
+				},
+				remoteTags: tc.remote,
+				git:        mockGit,
+			}
+
+			err := p.checkTagCommits()
+			mockGit.AssertExpectations(t)
+			if tc.expectedErr != nil {
+				assert.True(errors.Is(err, tc.expectedErr),
+					fmt.Errorf("error [%v] doesn't contain error [%v] in its err chain",
+						err, tc.expectedErr),
+				)
+				return
+			}
+			assert.NoError(err)
+			assert.Equal(tc.expected, p.tagReport)
+			if tc.expectedLog != "" {
+				assert.Contains(logs, tc.expectedLog)
+			} else {
+				assert.Empty(logs)
+			}
+		})
+	}
+}
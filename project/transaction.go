@@ -0,0 +1,166 @@
+// SPDX-FileCopyrightText: 2021 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+package project
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/spf13/afero"
+)
+
+// undo is a step that reverses one change made by the release.
+type undo struct {
+	desc string
+	fn   func() error
+}
+
+// transaction collects the undo steps of the release so a failure leaves the
+// workspace as it was before the release started.
+type transaction struct {
+	log   func(string, ...interface{})
+	undos []undo
+}
+
+// onRollback adds a step that is run if the release is rolled back.
+func (t *transaction) onRollback(desc string, fn func() error) {
+	t.undos = append(t.undos, undo{desc: desc, fn: fn})
+}
+
+// rollback runs the undo steps newest first and reports each one.  Every step
+// is attempted even if some fail.  The error that caused the rollback is
+// returned along with the steps that failed.
+func (t *transaction) rollback(cause error) error {
+	t.log("Rolling back the release: %s", cause)
+
+	var failed []string
+	for i := len(t.undos) - 1; i >= 0; i-- {
+		u := t.undos[i]
+		if err := u.fn(); err != nil {
+			t.log("Unable to roll back %s: %s", u.desc, err)
+			failed = append(failed, u.desc)
+			continue
+		}
+		t.log("Rolled back %s.", u.desc)
+	}
+	t.undos = nil
+
+	if len(failed) > 0 {
+		return fmt.Errorf("%w: unable to roll back %s", cause, strings.Join(failed, ", "))
+	}
+
+	return fmt.Errorf("%w: the release was rolled back", cause)
+}
+
+// trackFile records the file so a rollback restores the original contents or
+// removes the file if it didn't exist.
+func (t *transaction) trackFile(fs *afero.Afero, file string) error {
+	found, err := fs.Exists(file)
+	if err != nil {
+		return fmt.Errorf("%w: unable to check file '%s'", err, file)
+	}
+
+	if !found {
+		t.onRollback("the file "+file, func() error {
+			return removeIfPresent(fs, file)
+		})
+		return nil
+	}
+
+	buf, err := fs.ReadFile(file)
+	if err != nil {
+		return fmt.Errorf("%w: unable to read file '%s'", err, file)
+	}
+	t.onRollback("the file "+file, func() error {
+		return fs.WriteFile(file, buf, 0644)
+	})
+
+	return nil
+}
+
+// trackDir records the directory and the files in it so a rollback removes
+// the files created in it, restores the files that were overwritten and
+// removes the directory along with any parents that didn't exist.
+func (t *transaction) trackDir(fs *afero.Afero, dir string) error {
+	created, err := missingParent(fs, dir)
+	if err != nil {
+		return err
+	}
+	if created != "" {
+		t.onRollback("the directory "+created, func() error {
+			return fs.RemoveAll(created)
+		})
+		return nil
+	}
+
+	files, err := fs.ReadDir(dir)
+	if err != nil {
+		return fmt.Errorf("%w: unable to read directory '%s'", err, dir)
+	}
+
+	existing := map[string][]byte{}
+	for _, fi := range files {
+		if !fi.Mode().IsRegular() {
+			existing[fi.Name()] = nil
+			continue
+		}
+		file := dir + "/" + fi.Name()
+		buf, err := fs.ReadFile(file)
+		if err != nil {
+			return fmt.Errorf("%w: unable to read file '%s'", err, file)
+		}
+		existing[fi.Name()] = buf
+	}
+
+	t.onRollback("the files in "+dir, func() error {
+		files, err := fs.ReadDir(dir)
+		if err != nil {
+			return err
+		}
+		for _, fi := range files {
+			file := dir + "/" + fi.Name()
+			buf, found := existing[fi.Name()]
+			switch {
+			case !found:
+				err = fs.RemoveAll(file)
+			case fi.Mode().IsRegular():
+				err = fs.WriteFile(file, buf, fi.Mode().Perm())
+			}
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+
+	return nil
+}
+
+// missingParent returns the outermost directory of the path that doesn't
+// exist, or an empty string if the whole path exists.
+func missingParent(fs *afero.Afero, dir string) (string, error) {
+	var missing string
+	for d := path.Clean(dir); ; d = path.Dir(d) {
+		found, err := fs.Exists(d)
+		if err != nil {
+			return "", fmt.Errorf("%w: directory check failed for: '%s'", err, d)
+		}
+		if found {
+			return missing, nil
+		}
+		missing = d
+		if d == path.Dir(d) {
+			return missing, nil
+		}
+	}
+}
+
+func removeIfPresent(fs *afero.Afero, file string) error {
+	err := fs.Remove(file)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,155 @@
+// SPDX-FileCopyrightText: 2021 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+package project
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	changelog "github.com/xmidt-org/gokeepachangelog"
+	"github.com/xmidt-org/release-builder-action/git"
+)
+
+func TestTransaction(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	fs := &afero.Afero{Fs: afero.NewMemMapFs()}
+	require.NoError(writeFile(fs, "existing/old.txt", "old"))
+	require.NoError(writeFile(fs, "body.md", "body"))
+
+	tx := &transaction{log: func(string, ...interface{}) {}}
+	require.NoError(tx.trackDir(fs, "existing"))
+	require.NoError(tx.trackDir(fs, "new/dir"))
+	require.NoError(tx.trackFile(fs, "body.md"))
+	require.NoError(tx.trackFile(fs, "missing.md"))
+
+	require.NoError(writeFile(fs, "existing/old.txt", "changed"))
+	require.NoError(writeFile(fs, "existing/new.txt", "new"))
+	require.NoError(writeFile(fs, "new/dir/file.txt", "new"))
+	require.NoError(writeFile(fs, "body.md", "changed"))
+	require.NoError(writeFile(fs, "missing.md", "new"))
+
+	var undone []string
+	tx.onRollback("the custom step", func() error {
+		undone = append(undone, "custom")
+		return errors.New("failed")
+	})
+
+	cause := errors.New("cause")
+	err := tx.rollback(cause)
+	assert.ErrorIs(err, cause)
+	assert.ErrorContains(err, "the custom step")
+	assert.Equal([]string{"custom"}, undone)
+
+	buf, err := fs.ReadFile("existing/old.txt")
+	assert.NoError(err)
+	assert.Equal("old", string(buf))
+	buf, err = fs.ReadFile("body.md")
+	assert.NoError(err)
+	assert.Equal("body", string(buf))
+
+	for _, gone := range []string{"existing/new.txt", "new", "missing.md"} {
+		found, err := fs.Exists(gone)
+		assert.NoError(err)
+		assert.False(found, gone)
+	}
+}
+
+func TestReleaseRollback(t *testing.T) {
+	unknownErr := errors.New("unknown")
+
+	tests := []struct {
+		description string
+		tgzErr      error
+		pushErr     error
+		published   bool
+	}{
+		{
+			description: "archive fails",
+			tgzErr:      unknownErr,
+		}, {
+			description: "push fails",
+			pushErr:     unknownErr,
+		}, {
+			description: "push fails after the tags were published",
+			pushErr:     fmt.Errorf("%w: %w", git.ErrPushIncomplete, unknownErr),
+			published:   true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			assert := assert.New(t)
+
+			fs := &afero.Afero{Fs: afero.NewMemMapFs()}
+			require.NoError(t, writeFile(fs, ".release-body.md", "old body"))
+
+			mockGit := &mockGit{}
+			mockGit.On("TagHead", "v1.1.0", mock.Anything).Return(nil)
+			if !tc.published {
+				mockGit.On("DeleteTag", "v1.1.0").Return(nil)
+			}
+			mockGit.On("CreateArchive", "bar-1.1.0", "v1.1.0", "", git.Format{Name: "zip"}, "./artifacts", mock.Anything).
+				Run(func(mock.Arguments) {
+					require.NoError(t, writeFile(fs, "./artifacts/bar-1.1.0.zip", "zip"))
+				}).
+				Return(git.Archive{File: "./artifacts/bar-1.1.0.zip"}, nil)
+			mockGit.On("CreateArchive", "bar-1.1.0", "v1.1.0", "", git.Format{Name: "tar.gz"}, "./artifacts", mock.Anything).
+				Return(git.Archive{File: "./artifacts/bar-1.1.0.tar.gz"}, tc.tgzErr)
+			if tc.tgzErr == nil {
+				mockGit.On("PushTags", "token", []string{"v1.1.0"}).Return(tc.pushErr)
+			}
+
+			rel := &changelog.Release{
+				Version: "v1.1.0",
+				Body:    []string{"## [v1.1.0]", "- new body"},
+			}
+			p := &Project{
+				opts: ProjectOpts{
+					BasePath:    ".",
+					Token:       "token",
+					TagPrefix:   "v",
+					ArtifactDir: "artifacts",
+					SHASumFile:  "sha256sum.txt",
+					Log:         func(string, ...interface{}) {},
+				},
+				repoName:    "bar",
+				fs:          fs,
+				nextRelease: rel,
+				releases: []*release{
+					{
+						rel:      rel,
+						version:  semver{Major: 1, Minor: 1},
+						tag:      "v1.1.0",
+						artDir:   "artifacts",
+						bodyFile: ".release-body.md",
+					},
+				},
+				git: mockGit,
+			}
+
+			err := p.Release()
+			assert.ErrorIs(err, unknownErr)
+			mockGit.AssertExpectations(t)
+
+			found, err := fs.Exists("artifacts")
+			assert.NoError(err)
+			assert.Equal(tc.published, found)
+
+			buf, err := fs.ReadFile(".release-body.md")
+			assert.NoError(err)
+			if tc.published {
+				mockGit.AssertNotCalled(t, "DeleteTag", mock.Anything)
+				assert.NotEqual("old body", string(buf))
+				return
+			}
+			assert.Equal("old body", string(buf))
+		})
+	}
+}
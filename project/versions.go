@@ -0,0 +1,414 @@
+// SPDX-FileCopyrightText: 2021 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+package project
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/afero"
+)
+
+var (
+	errExtractorUnknown = errors.New("the version extractor is unknown")
+	errManifestParse    = errors.New("unable to parse the manifest file")
+)
+
+// versionFound is a version found in a manifest file.
+type versionFound struct {
+	Line    int
+	Version string
+}
+
+// versionExtractor finds the version in a kind of manifest file.
+type versionExtractor struct {
+	// Files are the file names the extractor handles.
+	Files []string
+
+	// Recursive is set if the files are searched for in every directory
+	// instead of only the top directory of the project.
+	Recursive bool
+
+	// CoreOnly is set if the file can only hold the major.minor.patch
+	// portion of a version.
+	CoreOnly bool
+
+	// Extract returns the versions found in the contents of a file.
+	Extract func(contents string) ([]versionFound, error)
+}
+
+var versionExtractors = map[string]versionExtractor{}
+
+// registerVersionExtractor adds an extractor to the set that may be used.
+func registerVersionExtractor(name string, e versionExtractor) {
+	versionExtractors[name] = e
+}
+
+func init() {
+	registerVersionExtractor("package-json", versionExtractor{
+		Files:   []string{"package.json"},
+		Extract: extractPackageJSON,
+	})
+	registerVersionExtractor("cargo", versionExtractor{
+		Files:   []string{"Cargo.toml"},
+		Extract: tomlVersionExtractor("package", "workspace.package"),
+	})
+	registerVersionExtractor("pyproject", versionExtractor{
+		Files:   []string{"pyproject.toml"},
+		Extract: tomlVersionExtractor("project", "tool.poetry"),
+	})
+	registerVersionExtractor("cmake", versionExtractor{
+		Files:    []string{"CMakeLists.txt"},
+		CoreOnly: true,
+		Extract:  extractCMake,
+	})
+	registerVersionExtractor("version-file", versionExtractor{
+		Files:   []string{"VERSION"},
+		Extract: extractVersionFile,
+	})
+	registerVersionExtractor("go", versionExtractor{
+		Files:     []string{"version.go"},
+		Recursive: true,
+		Extract:   extractGoVersion,
+	})
+}
+
+// versionExtractorNames returns the names of every registered extractor.
+func versionExtractorNames() []string {
+	names := make([]string, 0, len(versionExtractors))
+	for name := range versionExtractors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// lineOf returns the 1 based line number of the byte offset.
+func lineOf(contents string, offset int) int {
+	return strings.Count(contents[:offset], "\n") + 1
+}
+
+var (
+	packageJSONVersionRE = regexp.MustCompile(`"version"\s*:\s*"((?:[^"\\]|\\.)*)"`)
+)
+
+func extractPackageJSON(contents string) ([]versionFound, error) {
+	var pkg struct {
+		Version *string `json:"version"`
+	}
+	if err := json.Unmarshal([]byte(contents), &pkg); err != nil {
+		return nil, fmt.Errorf("%w: %s", errManifestParse, err)
+	}
+	if pkg.Version == nil {
+		return nil, nil
+	}
+
+	// The line of the first "version" key holding the value is reported.
+	line := 0
+	for _, loc := range packageJSONVersionRE.FindAllStringSubmatchIndex(contents, -1) {
+		var v string
+		if err := json.Unmarshal([]byte(contents[loc[2]-1:loc[3]+1]), &v); err == nil && v == *pkg.Version {
+			line = lineOf(contents, loc[0])
+			break
+		}
+	}
+
+	return []versionFound{{Line: line, Version: *pkg.Version}}, nil
+}
+
+var (
+	tomlTableRE   = regexp.MustCompile(`^\[\s*([A-Za-z0-9_.\-"]+)\s*\]\s*(#.*)?$`)
+	tomlVersionRE = regexp.MustCompile(`^version\s*=\s*["']([^"']*)["']\s*(#.*)?$`)
+)
+
+// tomlVersionExtractor returns an extractor for the 'version = "x"' key of
+// the specified tables.
+func tomlVersionExtractor(tables ...string) func(string) ([]versionFound, error) {
+	return func(contents string) ([]versionFound, error) {
+		var found []versionFound
+		table := ""
+		for i, line := range strings.Split(contents, "\n") {
+			line = strings.TrimSpace(line)
+			if m := tomlTableRE.FindStringSubmatch(line); m != nil {
+				table = strings.ReplaceAll(m[1], `"`, "")
+				continue
+			}
+
+			m := tomlVersionRE.FindStringSubmatch(line)
+			if m == nil {
+				continue
+			}
+			for _, t := range tables {
+				if t == table {
+					found = append(found, versionFound{Line: i + 1, Version: m[1]})
+				}
+			}
+		}
+		return found, nil
+	}
+}
+
+var (
+	cmakeProjectRE = regexp.MustCompile(`(?is)\bproject\s*\(([^)]*)\)`)
+	cmakeVersionRE = regexp.MustCompile(`(?s)\bVERSION\s+"?([^\s")]+)"?`)
+)
+
+func extractCMake(contents string) ([]versionFound, error) {
+	var found []versionFound
+	for _, m := range cmakeProjectRE.FindAllStringSubmatchIndex(contents, -1) {
+		args := contents[m[2]:m[3]]
+		v := cmakeVersionRE.FindStringSubmatchIndex(args)
+		if v == nil {
+			continue
+		}
+		found = append(found, versionFound{
+			Line:    lineOf(contents, m[2]+v[2]),
+			Version: args[v[2]:v[3]],
+		})
+	}
+	return found, nil
+}
+
+func extractVersionFile(contents string) ([]versionFound, error) {
+	for i, line := range strings.Split(contents, "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" {
+			return []versionFound{{Line: i + 1, Version: line}}, nil
+		}
+	}
+	return nil, nil
+}
+
+// extractGoVersion returns the Version or version constants and variables
+// declared at the top level of the file.  Assignments inside functions are
+// not the version of the project.
+func extractGoVersion(contents string) ([]versionFound, error) {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "version.go", contents, parser.SkipObjectResolution)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", errManifestParse, err)
+	}
+
+	var found []versionFound
+	for _, decl := range f.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || (gen.Tok != token.CONST && gen.Tok != token.VAR) {
+			continue
+		}
+
+		for _, spec := range gen.Specs {
+			vs := spec.(*ast.ValueSpec)
+			for i, name := range vs.Names {
+				if (name.Name != "Version" && name.Name != "version") || i >= len(vs.Values) {
+					continue
+				}
+				lit, ok := vs.Values[i].(*ast.BasicLit)
+				if !ok || lit.Kind != token.STRING {
+					continue
+				}
+				v, err := strconv.Unquote(lit.Value)
+				if err != nil {
+					continue
+				}
+				found = append(found, versionFound{
+					Line:    fset.Position(lit.Pos()).Line,
+					Version: v,
+				})
+			}
+		}
+	}
+	return found, nil
+}
+
+// examineVersionFiles compares the versions found by the configured
+// extractors with the newest release.  Every mismatch is reported before
+// failing.
+func (p *Project) examineVersionFiles() error {
+	names := p.opts.VersionFiles
+	if len(names) == 1 && names[0] == "all" {
+		names = versionExtractorNames()
+	}
+	if len(names) == 0 {
+		return nil
+	}
+
+	// The files are read from the commit being tagged, which may not be the
+	// working tree.
+	r := p.releases[0]
+	root := p.componentPath()
+
+	var problems []Problem
+	for _, name := range names {
+		e, found := versionExtractors[name]
+		if !found {
+			return fmt.Errorf("%w: '%s', expected one of: %s", errExtractorUnknown,
+				name, strings.Join(versionExtractorNames(), ", "))
+		}
+
+		files, err := p.findManifests(r, root, e)
+		if err != nil {
+			return err
+		}
+
+		for _, file := range files {
+			buf, found, err := p.readReleaseFile(r, file)
+			if err != nil {
+				return err
+			}
+			if !found {
+				continue
+			}
+
+			versions, err := e.Extract(string(buf))
+			if err != nil {
+				return fmt.Errorf("%w: '%s'", err, file)
+			}
+
+			for _, v := range versions {
+				if p.versionMatches(v.Version, r.version, e.CoreOnly) {
+					continue
+				}
+				problems = append(problems, Problem{
+					File:    file,
+					Line:    v.Line,
+					Message: fmt.Sprintf("the version '%s' doesn't match the release '%s'", v.Version, r.version),
+				})
+			}
+		}
+	}
+
+	for _, problem := range problems {
+		p.opts.Log("%s", problem)
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("%w: %d file version(s) don't match the release '%s'",
+			errVersionMismatch, len(problems), r.tag)
+	}
+
+	return nil
+}
+
+// findManifests returns the files, relative to the base path, the extractor
+// may handle.  Files that aren't recursively searched for may not exist.
+func (p *Project) findManifests(r *release, root string, e versionExtractor) ([]string, error) {
+	var files []string
+
+	if !e.Recursive {
+		for _, name := range e.Files {
+			files = append(files, path.Join(root, name))
+		}
+		return files, nil
+	}
+
+	all, err := p.listReleaseFiles(r, root, p.skipManifestDir)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, file := range all {
+		for _, name := range e.Files {
+			if path.Base(file) == name {
+				files = append(files, file)
+			}
+		}
+	}
+
+	return files, nil
+}
+
+// skipManifestDir returns true if the manifest files in the directory, relative
+// to the base path, aren't part of the project.  The directories of other
+// components are released on their own.
+func (p *Project) skipManifestDir(dir string) bool {
+	switch path.Base(dir) {
+	case ".git", "vendor", "testdata", "node_modules":
+		return true
+	}
+	for _, nested := range p.nested {
+		if dir == nested {
+			return true
+		}
+	}
+	return false
+}
+
+// listReleaseFiles returns the files below the directory, relative to the
+// base path, from the commit the release is tagged on.  The working tree is
+// listed when the release is tagged at the head.  Directories below the
+// directory the skip function returns true for are left out.
+func (p *Project) listReleaseFiles(r *release, dir string, skip func(string) bool) ([]string, error) {
+	var files []string
+
+	if r.commit != "" {
+		all, err := p.git.ListFiles(r.commit, dir)
+		if err != nil {
+			return nil, fmt.Errorf("%w: unable to search directory '%s'", err, dir)
+		}
+
+	next:
+		for _, file := range all {
+			for parent := path.Dir(file); parent != "." && parent != dir; parent = path.Dir(parent) {
+				if skip(parent) {
+					continue next
+				}
+			}
+			files = append(files, file)
+		}
+		return files, nil
+	}
+
+	root := path.Join(p.opts.BasePath, dir)
+	err := afero.Walk(p.fs, root, func(file string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(root, file)
+		if err != nil {
+			return err
+		}
+		rel = path.Join(dir, filepath.ToSlash(rel))
+
+		if info.IsDir() {
+			if file != root && skip(rel) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		files = append(files, rel)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w: unable to search directory '%s'", err, root)
+	}
+
+	return files, nil
+}
+
+// versionMatches returns true if the version found in a file is the same as
+// the release version.  The version may have the tag prefix or a 'v'.
+func (p *Project) versionMatches(found string, v semver, coreOnly bool) bool {
+	if coreOnly && found == v.Core() {
+		return true
+	}
+
+	fv, err := p.parseVersion(found)
+	if err != nil {
+		if fv, err = parseSemver(found, "v"); err != nil {
+			return false
+		}
+	}
+
+	return fv.Compare(v) == 0
+}
@@ -0,0 +1,322 @@
+// SPDX-FileCopyrightText: 2021 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+package project
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/xmidt-org/release-builder-action/git"
+)
+
+func TestVersionExtractors(t *testing.T) {
+	tests := []struct {
+		description string
+		extractor   string
+		contents    string
+		expected    []versionFound
+		expectedErr error
+	}{
+		{
+			description: "package.json",
+			extractor:   "package-json",
+			contents: `{
+  "name": "foo",
+  "dependencies": {
+    "bar": "^1.0.0"
+  },
+  "version": "1.2.3"
+}`,
+			expected: []versionFound{{Line: 6, Version: "1.2.3"}},
+		}, {
+			description: "package.json with a nested version first",
+			extractor:   "package-json",
+			contents: `{
+  "engines": {"version": "0.9.0"},
+  "version": "1.2.3-\u0072c.1"
+}`,
+			expected: []versionFound{{Line: 3, Version: "1.2.3-rc.1"}},
+		}, {
+			description: "package.json without a version",
+			extractor:   "package-json",
+			contents:    `{"name": "foo"}`,
+		}, {
+			description: "invalid package.json",
+			extractor:   "package-json",
+			contents:    `{"name": `,
+			expectedErr: errManifestParse,
+		}, {
+			description: "Cargo.toml",
+			extractor:   "cargo",
+			contents: `[package]
+name = "foo"
+version = "1.2.3" # comment
+
+[dependencies]
+version = "0.1.0"
+`,
+			expected: []versionFound{{Line: 3, Version: "1.2.3"}},
+		}, {
+			description: "Cargo.toml workspace",
+			extractor:   "cargo",
+			contents: `[workspace]
+members = ["a"]
+
+[workspace.package]
+version = '1.2.3'
+`,
+			expected: []versionFound{{Line: 5, Version: "1.2.3"}},
+		}, {
+			description: "pyproject.toml",
+			extractor:   "pyproject",
+			contents: `[build-system]
+requires = ["hatchling"]
+
+[project]
+name = "foo"
+version = "1.2.3rc1"
+`,
+			expected: []versionFound{{Line: 6, Version: "1.2.3rc1"}},
+		}, {
+			description: "CMakeLists.txt",
+			extractor:   "cmake",
+			contents: `cmake_minimum_required(VERSION 3.16)
+PROJECT(foo
+        VERSION 1.2.3
+        LANGUAGES C)
+`,
+			expected: []versionFound{{Line: 3, Version: "1.2.3"}},
+		}, {
+			description: "CMakeLists.txt without a version",
+			extractor:   "cmake",
+			contents:    "project(foo C)\n",
+		}, {
+			description: "VERSION",
+			extractor:   "version-file",
+			contents:    "\nv1.2.3\n",
+			expected:    []versionFound{{Line: 2, Version: "v1.2.3"}},
+		}, {
+			description: "version.go",
+			extractor:   "go",
+			contents: `package foo
+
+const (
+	Version = "v1.2.3"
+	minGoVersion = "1.20"
+)
+
+var version string = "1.2.3"
+`,
+			expected: []versionFound{
+				{Line: 4, Version: "v1.2.3"},
+				{Line: 8, Version: "1.2.3"},
+			},
+		}, {
+			description: "version.go inside functions",
+			extractor:   "go",
+			contents: `package foo
+
+var Version = ` + "`v1.2.3`" + `
+
+func init() {
+	version := "0.0.1"
+	const Version = "0.0.2"
+	_ = version
+}
+
+func set() {
+	Version = "0.0.3"
+}
+`,
+			expected: []versionFound{{Line: 3, Version: "v1.2.3"}},
+		}, {
+			description: "version.go not Go",
+			extractor:   "go",
+			contents:    "Version = \"1.2.3\"\n",
+			expectedErr: errManifestParse,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			assert := assert.New(t)
+
+			e, found := versionExtractors[tc.extractor]
+			require.True(t, found)
+
+			got, err := e.Extract(tc.contents)
+			if tc.expectedErr != nil {
+				assert.True(errors.Is(err, tc.expectedErr),
+					fmt.Errorf("error [%v] doesn't contain error [%v] in its err chain",
+						err, tc.expectedErr),
+				)
+				return
+			}
+			assert.NoError(err)
+			assert.Equal(tc.expected, got)
+		})
+	}
+}
+
+func TestExamineVersionFiles(t *testing.T) {
+	tests := []struct {
+		description string
+		extractors  []string
+		version     string
+		files       map[string]string
+		nested      []string
+		commit      string
+		committed   map[string]string
+		expected    []string
+		expectedErr error
+	}{
+		{
+			description: "nothing configured",
+			version:     "1.2.3",
+			files: map[string]string{
+				"VERSION": "0.0.1",
+			},
+		}, {
+			description: "all match",
+			extractors:  []string{"all"},
+			version:     "1.2.3-rc.1",
+			files: map[string]string{
+				"VERSION":             "v1.2.3-rc.1",
+				"CMakeLists.txt":      "project(foo VERSION 1.2.3)",
+				"package.json":        `{"version": "1.2.3-rc.1"}`,
+				"pkg/version.go":      "package pkg\n\nconst Version = \"1.2.3-rc.1\"\n",
+				"vendor/x/version.go": "package x\n\nconst Version = \"9.9.9\"\n",
+			},
+		}, {
+			description: "every mismatch is reported",
+			extractors:  []string{"version-file", "go", "cmake"},
+			version:     "1.2.3",
+			files: map[string]string{
+				"VERSION":        "1.2.2",
+				"CMakeLists.txt": "project(foo\n  VERSION 1.2)",
+				"a/version.go":   "package a\n\nconst Version = \"v1.2.3\"\n",
+				"b/c/version.go": "package c\n\nconst Version = \"v1.0.0\"\n",
+			},
+			expected: []string{
+				"VERSION:1: the version '1.2.2' doesn't match the release '1.2.3'",
+				"b/c/version.go:3: the version 'v1.0.0' doesn't match the release '1.2.3'",
+				"CMakeLists.txt:2: the version '1.2' doesn't match the release '1.2.3'",
+			},
+			expectedErr: errVersionMismatch,
+		}, {
+			description: "tagged commit",
+			extractors:  []string{"version-file", "go", "package-json"},
+			version:     "1.2.3",
+			files: map[string]string{
+				"VERSION":        "1.2.3",
+				"a/version.go":   "package a\n\nconst Version = \"v1.2.3\"\n",
+				"b/c/version.go": "package c\n\nconst Version = \"v1.2.3\"\n",
+			},
+			commit: "abc123",
+			committed: map[string]string{
+				"VERSION":             "1.2.2",
+				"a/version.go":        "package a\n\nconst Version = \"v1.2.3\"\n",
+				"b/c/version.go":      "package c\n\nconst Version = \"v1.0.0\"\n",
+				"vendor/x/version.go": "package x\n\nconst Version = \"9.9.9\"\n",
+			},
+			expected: []string{
+				"VERSION:1: the version '1.2.2' doesn't match the release '1.2.3'",
+				"b/c/version.go:3: the version 'v1.0.0' doesn't match the release '1.2.3'",
+			},
+			expectedErr: errVersionMismatch,
+		}, {
+			description: "nested component",
+			extractors:  []string{"go"},
+			version:     "1.2.3",
+			files: map[string]string{
+				"version.go":         "package foo\n\nconst Version = \"v1.2.3\"\n",
+				"lib/version.go":     "package lib\n\nconst Version = \"v0.4.0\"\n",
+				"lib/sub/version.go": "package sub\n\nconst Version = \"v0.4.0\"\n",
+				"cmd/version.go":     "package main\n\nconst Version = \"v1.2.2\"\n",
+			},
+			nested: []string{"lib"},
+			expected: []string{
+				"cmd/version.go:3: the version 'v1.2.2' doesn't match the release '1.2.3'",
+			},
+			expectedErr: errVersionMismatch,
+		}, {
+			description: "nested component in the tagged commit",
+			extractors:  []string{"go"},
+			version:     "1.2.3",
+			nested:      []string{"lib"},
+			commit:      "abc123",
+			committed: map[string]string{
+				"version.go":         "package foo\n\nconst Version = \"v1.2.3\"\n",
+				"lib/version.go":     "package lib\n\nconst Version = \"v0.4.0\"\n",
+				"lib/sub/version.go": "package sub\n\nconst Version = \"v0.4.0\"\n",
+				"cmd/version.go":     "package main\n\nconst Version = \"v1.2.2\"\n",
+			},
+			expected: []string{
+				"cmd/version.go:3: the version 'v1.2.2' doesn't match the release '1.2.3'",
+			},
+			expectedErr: errVersionMismatch,
+		}, {
+			description: "unknown extractor",
+			extractors:  []string{"gradle"},
+			version:     "1.2.3",
+			expectedErr: errExtractorUnknown,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			assert := assert.New(t)
+
+			var committed []string
+			mockGit := &mockGit{}
+			for name, contents := range tc.committed {
+				committed = append(committed, name)
+				mockGit.On("ReadFile", tc.commit, name).Return([]byte(contents), nil)
+			}
+			sort.Strings(committed)
+			mockGit.On("ReadFile", tc.commit, mock.Anything).Return(nil, git.ErrFileNotFound)
+			mockGit.On("ListFiles", tc.commit, "").Return(committed, nil)
+
+			var logged []string
+			p := &Project{
+				opts: ProjectOpts{
+					BasePath:     "/repo",
+					TagPrefix:    "v",
+					VersionFiles: tc.extractors,
+					Log: func(format string, v ...interface{}) {
+						logged = append(logged, fmt.Sprintf(format, v...))
+					},
+				},
+				fs: &afero.Afero{
+					Fs: afero.NewMemMapFs(),
+				},
+				git:    mockGit,
+				nested: tc.nested,
+			}
+			for name, contents := range tc.files {
+				require.NoError(t, writeFile(p.fs, "/repo/"+name, contents))
+			}
+
+			v, err := parseSemver(tc.version, "")
+			require.NoError(t, err)
+			p.releases = []*release{{version: v, tag: "v" + tc.version, commit: tc.commit}}
+
+			err = p.examineVersionFiles()
+			assert.Equal(tc.expected, logged)
+			if tc.expectedErr != nil {
+				assert.True(errors.Is(err, tc.expectedErr),
+					fmt.Errorf("error [%v] doesn't contain error [%v] in its err chain",
+						err, tc.expectedErr),
+				)
+				return
+			}
+			assert.NoError(err)
+		})
+	}
+}